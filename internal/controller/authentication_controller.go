@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"fernandoglatz/url-management/internal/core/model/response"
+	"fernandoglatz/url-management/internal/core/port/service"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthenticationController struct {
+	service service.IApiKeyService
+}
+
+func NewAuthenticationController(service service.IApiKeyService) *AuthenticationController {
+	return &AuthenticationController{
+		service: service,
+	}
+}
+
+// @Tags		authentication
+// @Summary		Get API keys
+// @Produce		json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success		200	{array}		entity.ApiKey
+// @Failure		401	{object}	response.Response
+// @Failure		500	{object}	response.Response
+// @Router		/authentication [get]
+func (controller *AuthenticationController) Get(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	log.Info(ctx).Msg("Getting API keys")
+
+	apiKeys, err := controller.service.GetAll(ctx)
+	if err != nil {
+		HandleError(ctx, ginCtx, err)
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, apiKeys)
+}
+
+// @Tags		authentication
+// @Summary		Issue API key
+// @Description	The generated key is only returned once, store it safely
+// @Param		request	body	request.ApiKeyRequest true "body"
+// @Accept		json
+// @Produce		json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success		200	{object}	response.ApiKeyResponse
+// @Failure		400	{object}	response.Response
+// @Failure		401	{object}	response.Response
+// @Failure		500	{object}	response.Response
+// @Router		/authentication [put]
+func (controller *AuthenticationController) Put(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+
+	var apiKeyRequest request.ApiKeyRequest
+	err := ginCtx.ShouldBindJSON(&apiKeyRequest)
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidJSON,
+			Error:     err,
+		})
+		return
+	}
+
+	log.Info(ctx).Msg(fmt.Sprintf("Issuing API key %s", apiKeyRequest.Name))
+
+	apiKey, key, errw := controller.service.Create(ctx, apiKeyRequest.Name)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, response.ApiKeyResponse{
+		ApiKey: apiKey,
+		Key:    key,
+	})
+}
+
+// @Tags		authentication
+// @Summary		Revoke API key
+// @Param		id		path	string  true "id"
+// @Produce		json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success		204
+// @Failure		401	{object}	response.Response
+// @Failure		404	{object}	response.Response
+// @Failure		500	{object}	response.Response
+// @Router		/authentication/{id} [delete]
+func (controller *AuthenticationController) DeleteId(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	log.Info(ctx).Msg(fmt.Sprintf("Revoking API key %s", id))
+
+	apiKey, err := controller.service.Get(ctx, id)
+	if err != nil {
+		HandleError(ctx, ginCtx, err)
+		return
+	}
+
+	err = controller.service.Revoke(ctx, &apiKey)
+	if err != nil {
+		HandleError(ctx, ginCtx, err)
+	} else {
+		ginCtx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fernandoglatz/url-management/internal/core/entity"
+	balancingtype "fernandoglatz/url-management/internal/core/entity/balancing"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DEFAULT_STICKY_COOKIE = "um_upstream"
+	STICKY_SESSION_BYTES  = 16
+)
+
+// balancingKey returns the key hashed by IP_HASH and COOKIE_HASH balancing. For
+// COOKIE_HASH a new sticky session cookie is issued when the client has none.
+func balancingKey(ginCtx *gin.Context, redirect entity.Redirect) string {
+	balancing := redirect.Balancing
+	if balancing == nil || len(redirect.Upstreams) <= 1 {
+		return ""
+	}
+
+	switch balancing.Strategy {
+	case balancingtype.IP_HASH:
+		return ginCtx.ClientIP()
+
+	case balancingtype.COOKIE_HASH:
+		name := balancing.Cookie
+		if name == "" {
+			name = config.ApplicationConfig.Balancing.Cookie
+		}
+		if name == "" {
+			name = DEFAULT_STICKY_COOKIE
+		}
+
+		if session, err := ginCtx.Cookie(name); err == nil && session != "" {
+			return session
+		}
+
+		bytes := make([]byte, STICKY_SESSION_BYTES)
+		if _, err := rand.Read(bytes); err != nil {
+			return ginCtx.ClientIP()
+		}
+
+		session := hex.EncodeToString(bytes)
+		ginCtx.SetSameSite(http.SameSiteLaxMode)
+		ginCtx.SetCookie(name, session, 0, "/", "", ginCtx.Request.TLS != nil, true)
+		return session
+	}
+
+	return ""
+}
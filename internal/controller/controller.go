@@ -47,12 +47,22 @@ func HandleError(ctx context.Context, ginCtx *gin.Context, err *exceptions.Wrapp
 		log.Warn(ctx).Msg("[" + method + "] " + path + " - " + code + " - " + message)
 	}
 
-	if err.BaseError == exceptions.RecordNotFound {
+	switch err.BaseError {
+	case exceptions.RecordNotFound:
 		httpStatus = http.StatusNotFound
+	case exceptions.RecordAlreadyExists, exceptions.Conflict:
+		httpStatus = http.StatusConflict
+	case exceptions.RedirectExpired:
+		httpStatus = http.StatusGone
+	case exceptions.Unauthorized:
+		httpStatus = http.StatusUnauthorized
+	case exceptions.PreconditionFailed:
+		httpStatus = http.StatusPreconditionFailed
 	}
 
 	ginCtx.JSON(httpStatus, response.Response{
 		Code:    code,
 		Message: message,
+		Details: err.Details,
 	})
 }
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// rewriteCSS streams a stylesheet from reader to writer, rewriting the URLs of url()
+// and @import. Comments and strings are copied untouched, so URL-looking text inside
+// them is never altered.
+func (rewriter *urlRewriter) rewriteCSS(reader io.Reader, writer io.Writer) error {
+	in := bufio.NewReader(reader)
+	out := bufio.NewWriter(writer)
+
+	var previous byte
+	importPending := false
+
+	for {
+		current, err := in.ReadByte()
+		if err == io.EOF {
+			return out.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case current == '/' && peekFold(in, "*"):
+			out.WriteByte(current)
+			if err := copyCSSComment(in, out); err != nil {
+				return err
+			}
+
+		case current == '"' || current == '\'':
+			value, closed, err := readCSSString(in, current)
+			if err != nil {
+				return err
+			}
+			if importPending {
+				value = rewriter.rewriteURL(value, false)
+				importPending = false
+			}
+			writeCSSString(out, current, value, closed)
+
+		case current == '@':
+			name := readCSSIdent(in)
+			out.WriteByte(current)
+			out.WriteString(name)
+			importPending = strings.EqualFold(name, "import")
+			if name != "" {
+				current = name[len(name)-1]
+			}
+
+		case (current == 'u' || current == 'U') && !isCSSIdentByte(previous) && peekFold(in, "rl("):
+			prefix := make([]byte, 3)
+			io.ReadFull(in, prefix)
+			out.WriteByte(current)
+			out.Write(prefix)
+			if err := rewriter.rewriteCSSURL(in, out); err != nil {
+				return err
+			}
+			importPending = false
+			current = ')'
+
+		default:
+			if !isCSSSpace(current) {
+				importPending = false
+			}
+			out.WriteByte(current)
+		}
+
+		previous = current
+	}
+}
+
+// rewriteCSSString rewrites a stylesheet held in memory, such as a style attribute or
+// the content of a <style> element.
+func (rewriter *urlRewriter) rewriteCSSString(content string) string {
+	builder := &strings.Builder{}
+	if err := rewriter.rewriteCSS(strings.NewReader(content), builder); err != nil {
+		return content
+	}
+	return builder.String()
+}
+
+// rewriteCSSURL rewrites the argument of a url() whose "url(" was already written, up
+// to and including the closing parenthesis.
+func (rewriter *urlRewriter) rewriteCSSURL(in *bufio.Reader, out *bufio.Writer) error {
+	leading := readCSSSpaces(in)
+	out.WriteString(leading)
+
+	quote, err := in.ReadByte()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if quote == '"' || quote == '\'' {
+		value, closed, err := readCSSString(in, quote)
+		if err != nil {
+			return err
+		}
+		writeCSSString(out, quote, rewriter.rewriteURL(value, false), closed)
+		return nil
+	}
+
+	in.UnreadByte()
+	value, err := in.ReadString(')')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	closing := ""
+	if strings.HasSuffix(value, ")") {
+		value, closing = value[:len(value)-1], ")"
+	}
+
+	trimmed := strings.TrimRight(value, " \t\r\n\f")
+	out.WriteString(rewriter.rewriteURL(trimmed, false))
+	out.WriteString(value[len(trimmed):])
+	out.WriteString(closing)
+	return nil
+}
+
+func copyCSSComment(in *bufio.Reader, out *bufio.Writer) error {
+	var previous byte
+	for {
+		current, err := in.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		out.WriteByte(current)
+		if previous == '*' && current == '/' {
+			return nil
+		}
+		previous = current
+	}
+}
+
+// readCSSString reads a string whose opening quote was already consumed, returning its
+// raw content and whether it was closed.
+func readCSSString(in *bufio.Reader, quote byte) (string, bool, error) {
+	builder := strings.Builder{}
+	for {
+		current, err := in.ReadByte()
+		if err == io.EOF {
+			return builder.String(), false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		switch current {
+		case quote:
+			return builder.String(), true, nil
+
+		case '\\':
+			builder.WriteByte(current)
+			if escaped, err := in.ReadByte(); err == nil {
+				builder.WriteByte(escaped)
+			}
+
+		case '\n':
+			in.UnreadByte()
+			return builder.String(), false, nil
+
+		default:
+			builder.WriteByte(current)
+		}
+	}
+}
+
+func writeCSSString(out *bufio.Writer, quote byte, value string, closed bool) {
+	out.WriteByte(quote)
+	out.WriteString(value)
+	if closed {
+		out.WriteByte(quote)
+	}
+}
+
+func readCSSIdent(in *bufio.Reader) string {
+	builder := strings.Builder{}
+	for {
+		current, err := in.ReadByte()
+		if err != nil {
+			return builder.String()
+		}
+		if !isCSSIdentByte(current) {
+			in.UnreadByte()
+			return builder.String()
+		}
+		builder.WriteByte(current)
+	}
+}
+
+func readCSSSpaces(in *bufio.Reader) string {
+	builder := strings.Builder{}
+	for {
+		current, err := in.ReadByte()
+		if err != nil {
+			return builder.String()
+		}
+		if !isCSSSpace(current) {
+			in.UnreadByte()
+			return builder.String()
+		}
+		builder.WriteByte(current)
+	}
+}
+
+func peekFold(in *bufio.Reader, expected string) bool {
+	next, err := in.Peek(len(expected))
+	return err == nil && strings.EqualFold(string(next), expected)
+}
+
+func isCSSIdentByte(value byte) bool {
+	return value == '-' || value == '_' || value >= 0x80 ||
+		(value >= 'a' && value <= 'z') || (value >= 'A' && value <= 'Z') || (value >= '0' && value <= '9')
+}
+
+func isCSSSpace(value byte) bool {
+	return value == ' ' || value == '\t' || value == '\n' || value == '\r' || value == '\f'
+}
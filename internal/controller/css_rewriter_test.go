@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func rewriteCSSForTest(t *testing.T, rewriter *urlRewriter, content string) string {
+	t.Helper()
+
+	builder := &strings.Builder{}
+	if err := rewriter.rewriteCSS(strings.NewReader(content), builder); err != nil {
+		t.Fatalf("rewriteCSS: %v", err)
+	}
+	return builder.String()
+}
+
+func TestRewriteCSS(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetHost string
+		input     string
+		expected  string
+	}{
+		{
+			name:     "unquoted url()",
+			input:    `body { background: url(https://cdn.other.com/bg.png); }`,
+			expected: `body { background: url(` + TEST_CDN_PREFIX + `cdn.other.com/bg.png); }`,
+		},
+		{
+			name:     "quoted url() with spaces",
+			input:    `body { background: url( "https://cdn.other.com/bg.png" ); }`,
+			expected: `body { background: url( "` + TEST_CDN_PREFIX + `cdn.other.com/bg.png" ); }`,
+		},
+		{
+			name:     "uppercase URL()",
+			input:    `body { background: URL('//cdn.other.com/bg.png'); }`,
+			expected: `body { background: URL('` + TEST_CDN_PREFIX + `cdn.other.com/bg.png'); }`,
+		},
+		{
+			name:     "url() to the destination stays on the proxy",
+			input:    `@font-face { src: url(https://www.example.com/fonts/a.woff2) format("woff2"); }`,
+			expected: `@font-face { src: url(https://proxy.test/fonts/a.woff2) format("woff2"); }`,
+		},
+		{
+			name:     "@import of a string",
+			input:    `@import "https://cdn.other.com/base.css";`,
+			expected: `@import "` + TEST_CDN_PREFIX + `cdn.other.com/base.css";`,
+		},
+		{
+			name:     "@import of a url()",
+			input:    `@import url('https://cdn.other.com/base.css') screen;`,
+			expected: `@import url('` + TEST_CDN_PREFIX + `cdn.other.com/base.css') screen;`,
+		},
+		{
+			name:     "strings outside @import are untouched",
+			input:    `.a::after { content: "https://cdn.other.com/x"; }`,
+			expected: `.a::after { content: "https://cdn.other.com/x"; }`,
+		},
+		{
+			name:     "comments are untouched",
+			input:    `/* url(https://cdn.other.com/x) */ .b { color: red; }`,
+			expected: `/* url(https://cdn.other.com/x) */ .b { color: red; }`,
+		},
+		{
+			name:     "identifiers ending in url are not url()",
+			input:    `.c { background: myurl(https://cdn.other.com/x); }`,
+			expected: `.c { background: myurl(https://cdn.other.com/x); }`,
+		},
+		{
+			name:     "data URIs are untouched",
+			input:    `.d { background: url(data:image/png;base64,AAAA); }`,
+			expected: `.d { background: url(data:image/png;base64,AAAA); }`,
+		},
+		{
+			name:     "root-relative url() is untouched on the destination host",
+			input:    `.e { background: url("/img/e.png"); }`,
+			expected: `.e { background: url("/img/e.png"); }`,
+		},
+		{
+			name:      "root-relative url() goes to the asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `.e { background: url("/img/e.png"); }`,
+			expected:  `.e { background: url("` + TEST_CDN_PREFIX + `assets.other.com/img/e.png"); }`,
+		},
+		{
+			name:      "root-relative @import goes to the asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `@import "/css/base.css";`,
+			expected:  `@import "` + TEST_CDN_PREFIX + `assets.other.com/css/base.css";`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := rewriteCSSForTest(t, newTestRewriter(test.assetHost), test.input)
+			if actual != test.expected {
+				t.Errorf("\ninput:    %s\nexpected: %s\nactual:   %s", test.input, test.expected, actual)
+			}
+		})
+	}
+}
+
+// TestRewriteCSSRegexParity checks that the tokenizer rewrites the references the
+// regex fallback already handled the same way.
+func TestRewriteCSSRegexParity(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetHost string
+		input     string
+	}{
+		{
+			name:  "unquoted url()",
+			input: `body { background: url(https://cdn.other.com/bg.png); }`,
+		},
+		{
+			name:  "quoted url()",
+			input: `body { background: url('https://cdn.other.com/bg.png'); }`,
+		},
+		{
+			name:  "url() to the destination",
+			input: `@font-face { src: url(https://www.example.com/fonts/a.woff2); }`,
+		},
+		{
+			name:  "@import of a string",
+			input: `@import "https://cdn.other.com/base.css";`,
+		},
+		{
+			name:  "@import of a url()",
+			input: `@import url("https://cdn.other.com/base.css");`,
+		},
+		{
+			name:  "root-relative url() on the destination host",
+			input: `.e { background: url("/img/e.png"); }`,
+		},
+		{
+			name:      "root-relative url() with an asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `.e { background: url("/img/e.png"); }`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rewriter := newTestRewriter(test.assetHost)
+
+			tokenized := rewriteCSSForTest(t, rewriter, test.input)
+			legacy := rewriter.rewriteRegex(test.input, TEST_PROXY_HOST)
+			if tokenized != legacy {
+				t.Errorf("\ninput:     %s\ntokenizer: %s\nregex:     %s", test.input, tokenized, legacy)
+			}
+		})
+	}
+}
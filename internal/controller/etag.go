@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ETAG_HEADER     = "ETag"
+	IF_MATCH_HEADER = "If-Match"
+	ANY_ETAG        = "*"
+)
+
+// etag is the strong entity tag of a redirect, its quoted version.
+func etag(redirect entity.Redirect) string {
+	return strconv.Quote(strconv.FormatInt(redirect.Version, 10))
+}
+
+func setETag(ginCtx *gin.Context, redirect entity.Redirect) {
+	ginCtx.Header(ETAG_HEADER, etag(redirect))
+}
+
+// checkIfMatch honours the If-Match header against the stored redirect, or against
+// none when it doesn't exist. Only strong tags match, and "*" matches any stored
+// redirect. Without the header every write is allowed.
+func checkIfMatch(ginCtx *gin.Context, redirect entity.Redirect, found bool) *exceptions.WrappedError {
+	ifMatch := ginCtx.GetHeader(IF_MATCH_HEADER)
+	if len(strings.TrimSpace(ifMatch)) == 0 {
+		return nil
+	}
+
+	if found {
+		current := etag(redirect)
+		for _, tag := range strings.Split(ifMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == ANY_ETAG || tag == current {
+				return nil
+			}
+		}
+	}
+
+	message := "Redirect [" + redirect.ID + "] doesn't exist"
+	if found {
+		message = "Redirect [" + redirect.ID + "] doesn't match " + IF_MATCH_HEADER + ", its current " + ETAG_HEADER + " is " + etag(redirect)
+	}
+
+	return &exceptions.WrappedError{
+		BaseError: exceptions.PreconditionFailed,
+		Message:   message,
+	}
+}
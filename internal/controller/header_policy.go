@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"fernandoglatz/url-management/internal/core/entity"
+	"net/http"
+)
+
+// strippedResponseHeaders returns the response headers that keep browsers from
+// rendering proxied pages, minus those the policy of the redirect keeps.
+func strippedResponseHeaders(policy *entity.HeaderPolicy) map[string]bool {
+	stripped := map[string]bool{
+		"X-Frame-Options":                     true,
+		"Content-Security-Policy":             true,
+		"Content-Security-Policy-Report-Only": true,
+		"Strict-Transport-Security":           true,
+	}
+
+	if policy == nil {
+		return stripped
+	}
+
+	if policy.StripCSP != nil && !*policy.StripCSP {
+		delete(stripped, "Content-Security-Policy")
+		delete(stripped, "Content-Security-Policy-Report-Only")
+	}
+	if policy.StripFrameOptions != nil && !*policy.StripFrameOptions {
+		delete(stripped, "X-Frame-Options")
+	}
+	if policy.StripHSTS != nil && !*policy.StripHSTS {
+		delete(stripped, "Strict-Transport-Security")
+	}
+
+	for _, key := range policy.Response.Keep {
+		delete(stripped, http.CanonicalHeaderKey(key))
+	}
+	for _, key := range policy.Response.Remove {
+		stripped[http.CanonicalHeaderKey(key)] = true
+	}
+
+	return stripped
+}
+
+func applyRequestHeaderRules(header http.Header, policy *entity.HeaderPolicy) {
+	if policy != nil {
+		applyHeaderRules(header, policy.Request)
+	}
+}
+
+func applyResponseHeaderRules(header http.Header, policy *entity.HeaderPolicy) {
+	if policy != nil {
+		applyHeaderRules(header, policy.Response)
+	}
+}
+
+func applyHeaderRules(header http.Header, rules entity.HeaderRules) {
+	for _, key := range rules.Remove {
+		header.Del(key)
+	}
+	for key, value := range rules.Set {
+		header.Set(key, value)
+	}
+	for key, value := range rules.Add {
+		header.Add(key, value)
+	}
+}
@@ -1,16 +1,21 @@
 package controller
 
 import (
+	"fernandoglatz/url-management/internal/core/model/response"
+	"fernandoglatz/url-management/internal/core/port/service"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type HealthController struct {
+	service service.IHealthService
 }
 
-func NewHealthController() *HealthController {
-	return &HealthController{}
+func NewHealthController(service service.IHealthService) *HealthController {
+	return &HealthController{
+		service: service,
+	}
 }
 
 // @Tags	health
@@ -23,3 +28,33 @@ func NewHealthController() *HealthController {
 func (healthController *HealthController) Health(ginCtx *gin.Context) {
 	ginCtx.String(http.StatusOK, "OK")
 }
+
+// @Tags	health
+// @Summary	Get liveness
+// @Description	Tells the process is up, without checking its dependencies
+// @Produce	json
+// @Success	200	{object}	response.HealthResponse
+// @Router	/health/live [get]
+func (healthController *HealthController) Live(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	ginCtx.JSON(http.StatusOK, healthController.service.Live(ctx))
+}
+
+// @Tags	health
+// @Summary	Get readiness
+// @Description	Checks MongoDB, Redis, the migrations and the cache, failing when a required one is down
+// @Produce	json
+// @Success	200	{object}	response.HealthResponse
+// @Failure	503	{object}	response.HealthResponse
+// @Router	/health/ready [get]
+func (healthController *HealthController) Ready(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	health := healthController.service.Ready(ctx)
+
+	status := http.StatusOK
+	if health.Status == response.HEALTH_STATUS_DOWN {
+		status = http.StatusServiceUnavailable
+	}
+
+	ginCtx.JSON(status, health)
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// rewriteHTML streams a page from reader to writer, rewriting the URLs of its tags.
+// Tokens that need no change are copied byte for byte, so text content, comments and
+// formatting are preserved; inline styles and <style> go through the CSS rewriter and
+// inline scripts through the quoted URL patterns.
+func (rewriter *urlRewriter) rewriteHTML(reader io.Reader, writer io.Writer) error {
+	tokenizer := html.NewTokenizer(reader)
+	out := bufio.NewWriter(writer)
+
+	rawElement := ""
+	rewritableScript := false
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				out.Flush()
+				return err
+			}
+			return out.Flush()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := append([]byte(nil), tokenizer.Raw()...)
+			token := tokenizer.Token()
+
+			if rewriter.rewriteTag(&token) {
+				out.WriteString(token.String())
+			} else {
+				out.Write(raw)
+			}
+
+			rawElement = ""
+			if tokenType == html.StartTagToken && (token.Data == "style" || token.Data == "script") {
+				rawElement = token.Data
+				rewritableScript = isRewritableScript(token)
+			}
+
+		case html.TextToken:
+			raw := tokenizer.Raw()
+
+			switch {
+			case rawElement == "style":
+				out.WriteString(rewriter.rewriteCSSString(string(raw)))
+			case rawElement == "script" && rewritableScript:
+				out.WriteString(rewriter.rewriteScript(string(raw)))
+			default:
+				out.Write(raw)
+			}
+
+		default:
+			rawElement = ""
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
+
+// rewriteTag rewrites the URL attributes of a tag, reporting whether any changed.
+func (rewriter *urlRewriter) rewriteTag(token *html.Token) bool {
+	changed := false
+	refresh := false
+	for _, attribute := range token.Attr {
+		if strings.EqualFold(attribute.Key, "http-equiv") && strings.EqualFold(strings.TrimSpace(attribute.Val), "refresh") {
+			refresh = true
+		}
+	}
+
+	attributes := make([]html.Attribute, 0, len(token.Attr))
+	for _, attribute := range token.Attr {
+		key := strings.ToLower(attribute.Key)
+		value := attribute.Val
+
+		switch {
+		// proxied content may be rewritten, so the original SRI hash would never match
+		case key == "integrity":
+			changed = true
+			continue
+
+		case strings.HasPrefix(key, "xmlns"):
+
+		case key == "srcset" || key == "imagesrcset":
+			value = rewriter.rewriteSrcset(value)
+
+		case key == "style":
+			value = rewriter.rewriteCSSString(value)
+
+		case key == "href" && (token.Data == "a" || token.Data == "area" || token.Data == "base"),
+			key == "action", key == "formaction":
+			value = rewriter.rewriteURL(value, true)
+
+		case key == "href", key == "src", key == "poster", key == "background", key == "xlink:href",
+			key == "data" && token.Data == "object":
+			value = rewriter.rewriteURL(value, false)
+
+		case key == "content" && refresh:
+			value = rewriter.rewriteRefresh(value)
+
+		case isAbsoluteURL(strings.TrimSpace(value)):
+			value = rewriter.rewriteAbsoluteURL(strings.TrimSpace(value))
+
+		case strings.HasPrefix(value, "{") || strings.HasPrefix(value, "["):
+			value = rewriter.rewriteScript(value)
+		}
+
+		if value != attribute.Val {
+			attribute.Val = value
+			changed = true
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	token.Attr = attributes
+	return changed
+}
+
+// isRewritableScript reports whether a <script> holds JavaScript or JSON, as opposed
+// to templates or other data blocks whose quoted URLs are not references.
+func isRewritableScript(token html.Token) bool {
+	for _, attribute := range token.Attr {
+		if attribute.Key != "type" {
+			continue
+		}
+
+		scriptType := strings.ToLower(strings.TrimSpace(attribute.Val))
+		return scriptType == "" || scriptType == "module" || scriptType == "importmap" ||
+			strings.Contains(scriptType, "javascript") || strings.Contains(scriptType, "json")
+	}
+
+	return true
+}
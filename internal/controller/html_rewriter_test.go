@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	TEST_PROXY_BASE  = "https://proxy.test"
+	TEST_PROXY_HOST  = "proxy.test"
+	TEST_ASSET_HOST  = "assets.other.com"
+	TEST_CDN_PREFIX  = TEST_PROXY_BASE + CDN_PATH_PREFIX
+	TEST_DESTINATION = "www.example.com"
+)
+
+func newTestRewriter(assetHost string) *urlRewriter {
+	return &urlRewriter{
+		proxyBase:             TEST_PROXY_BASE,
+		proxyHost:             TEST_PROXY_HOST,
+		destinationDomain:     TEST_DESTINATION,
+		destinationRootDomain: "example.com",
+		assetHost:             assetHost,
+	}
+}
+
+func rewriteHTMLForTest(t *testing.T, rewriter *urlRewriter, content string) string {
+	t.Helper()
+
+	builder := &strings.Builder{}
+	if err := rewriter.rewriteHTML(strings.NewReader(content), builder); err != nil {
+		t.Fatalf("rewriteHTML: %v", err)
+	}
+	return builder.String()
+}
+
+func TestRewriteHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetHost string
+		input     string
+		expected  string
+	}{
+		{
+			name:     "href to the destination stays on the proxy",
+			input:    `<a href="https://www.example.com/about">About</a>`,
+			expected: `<a href="https://proxy.test/about">About</a>`,
+		},
+		{
+			name:     "href to the root domain stays on the proxy",
+			input:    `<a href="https://example.com/about?x=1#top">About</a>`,
+			expected: `<a href="https://proxy.test/about?x=1#top">About</a>`,
+		},
+		{
+			name:     "href to another host goes through the CDN proxy",
+			input:    `<link rel="stylesheet" href="https://cdn.other.com/site.css">`,
+			expected: `<link rel="stylesheet" href="` + TEST_CDN_PREFIX + `cdn.other.com/site.css">`,
+		},
+		{
+			name:     "src",
+			input:    `<img src="https://cdn.other.com/img.png" alt="">`,
+			expected: `<img src="` + TEST_CDN_PREFIX + `cdn.other.com/img.png" alt="">`,
+		},
+		{
+			name:     "protocol-relative src",
+			input:    `<script src="//cdn.other.com/app.js"></script>`,
+			expected: `<script src="` + TEST_CDN_PREFIX + `cdn.other.com/app.js"></script>`,
+		},
+		{
+			name:     "srcset keeps its descriptors",
+			input:    `<img srcset="https://cdn.other.com/a.png 1x, https://cdn.other.com/b.png 2x">`,
+			expected: `<img srcset="` + TEST_CDN_PREFIX + `cdn.other.com/a.png 1x, ` + TEST_CDN_PREFIX + `cdn.other.com/b.png 2x">`,
+		},
+		{
+			name:     "action",
+			input:    `<form action="https://example.com/login" method="post"></form>`,
+			expected: `<form action="https://proxy.test/login" method="post"></form>`,
+		},
+		{
+			name:     "poster",
+			input:    `<video poster="https://cdn.other.com/poster.jpg"></video>`,
+			expected: `<video poster="` + TEST_CDN_PREFIX + `cdn.other.com/poster.jpg"></video>`,
+		},
+		{
+			name:     "inline style",
+			input:    `<div style="background: url(https://cdn.other.com/bg.png)"></div>`,
+			expected: `<div style="background: url(` + TEST_CDN_PREFIX + `cdn.other.com/bg.png)"></div>`,
+		},
+		{
+			name:     "style element",
+			input:    `<style>body { background: url("https://cdn.other.com/bg.png") }</style>`,
+			expected: `<style>body { background: url("` + TEST_CDN_PREFIX + `cdn.other.com/bg.png") }</style>`,
+		},
+		{
+			name:     "base",
+			input:    `<base href="https://www.example.com/app/">`,
+			expected: `<base href="https://proxy.test/app/">`,
+		},
+		{
+			name:     "meta refresh",
+			input:    `<meta http-equiv="refresh" content="5; url=https://cdn.other.com/next">`,
+			expected: `<meta http-equiv="refresh" content="5; url=` + TEST_CDN_PREFIX + `cdn.other.com/next">`,
+		},
+		{
+			name:     "quoted meta refresh",
+			input:    `<meta http-equiv="Refresh" content="0;URL='https://www.example.com/home'">`,
+			expected: `<meta http-equiv="Refresh" content="0; URL=&#39;https://proxy.test/home&#39;">`,
+		},
+		{
+			name:     "content of other meta tags is left alone",
+			input:    `<meta name="description" content="5; url=https://cdn.other.com/next">`,
+			expected: `<meta name="description" content="5; url=https://cdn.other.com/next">`,
+		},
+		{
+			name:     "integrity is dropped from rewritten content",
+			input:    `<script src="https://cdn.other.com/a.js" integrity="sha384-abc"></script>`,
+			expected: `<script src="` + TEST_CDN_PREFIX + `cdn.other.com/a.js"></script>`,
+		},
+		{
+			name:     "text and comments are untouched",
+			input:    `<p>Read https://www.example.com/about on www.example.com</p><!-- https://cdn.other.com/x -->`,
+			expected: `<p>Read https://www.example.com/about on www.example.com</p><!-- https://cdn.other.com/x -->`,
+		},
+		{
+			name:     "root-relative references are untouched on the destination host",
+			input:    `<img src="/img/logo.png"><a href="/about">About</a>`,
+			expected: `<img src="/img/logo.png"><a href="/about">About</a>`,
+		},
+		{
+			name:      "root-relative assets go to the asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `<img src="/img/logo.png"><link rel="stylesheet" href="/css/site.css">`,
+			expected:  `<img src="` + TEST_CDN_PREFIX + `assets.other.com/img/logo.png"><link rel="stylesheet" href="` + TEST_CDN_PREFIX + `assets.other.com/css/site.css">`,
+		},
+		{
+			name:      "root-relative navigation stays on the proxy with an asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `<a href="/about">About</a><form action="/search"></form>`,
+			expected:  `<a href="/about">About</a><form action="/search"></form>`,
+		},
+		{
+			name:      "root-relative url() goes to the asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `<div style="background: url(/img/bg.png)"></div>`,
+			expected:  `<div style="background: url(` + TEST_CDN_PREFIX + `assets.other.com/img/bg.png)"></div>`,
+		},
+		{
+			name:      "root-relative script paths go to the asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `<script>var config = {"chunk":"/static/app.js","home":"/about"};</script>`,
+			expected:  `<script>var config = {"chunk":"` + TEST_CDN_PREFIX + `assets.other.com/static/app.js","home":"/about"};</script>`,
+		},
+		{
+			name:     "quoted URLs of inline scripts",
+			input:    `<script>fetch("https://api.other.com/v1/items")</script>`,
+			expected: `<script>fetch("` + TEST_CDN_PREFIX + `api.other.com/v1/items")</script>`,
+		},
+		{
+			name:     "templates are not scripts",
+			input:    `<script type="text/template"><a href="https://cdn.other.com/x"></a></script>`,
+			expected: `<script type="text/template"><a href="https://cdn.other.com/x"></a></script>`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := rewriteHTMLForTest(t, newTestRewriter(test.assetHost), test.input)
+			if actual != test.expected {
+				t.Errorf("\ninput:    %s\nexpected: %s\nactual:   %s", test.input, test.expected, actual)
+			}
+		})
+	}
+}
+
+// TestRewriteHTMLRegexParity checks that the tokenizer rewrites the references the
+// regex fallback already handled the same way.
+func TestRewriteHTMLRegexParity(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetHost string
+		input     string
+	}{
+		{
+			name:  "href to the destination",
+			input: `<a href="https://www.example.com/about">About</a>`,
+		},
+		{
+			name:  "href to another host",
+			input: `<a href="https://cdn.other.com/x">x</a>`,
+		},
+		{
+			name:  "src",
+			input: `<img src="https://cdn.other.com/img.png">`,
+		},
+		{
+			name:  "protocol-relative poster",
+			input: `<video poster="//cdn.other.com/p.jpg"></video>`,
+		},
+		{
+			name:  "srcset",
+			input: `<img srcset="https://cdn.other.com/a.png 1x, https://cdn.other.com/b.png 2x">`,
+		},
+		{
+			name:  "action",
+			input: `<form action="https://www.example.com/login"></form>`,
+		},
+		{
+			name:  "base",
+			input: `<base href="https://www.example.com/app/">`,
+		},
+		{
+			name:  "inline style",
+			input: `<div style="background: url(https://cdn.other.com/bg.png)"></div>`,
+		},
+		{
+			name:  "integrity",
+			input: `<script src="https://cdn.other.com/a.js" integrity="sha384-abc"></script>`,
+		},
+		{
+			name:  "root-relative references on the destination host",
+			input: `<img src="/img/logo.png"><a href="/about">About</a>`,
+		},
+		{
+			name:      "root-relative assets with an asset host",
+			assetHost: TEST_ASSET_HOST,
+			input:     `<img src="/img/logo.png"><link rel="stylesheet" href="/css/site.css"><a href="/about">About</a>`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rewriter := newTestRewriter(test.assetHost)
+
+			tokenized := rewriteHTMLForTest(t, rewriter, test.input)
+			legacy := rewriter.rewriteRegex(test.input, TEST_PROXY_HOST)
+			if tokenized != legacy {
+				t.Errorf("\ninput:     %s\ntokenizer: %s\nregex:     %s", test.input, tokenized, legacy)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"fernandoglatz/url-management/internal/core/entity"
+	matchtype "fernandoglatz/url-management/internal/core/entity/match"
+	querytype "fernandoglatz/url-management/internal/core/entity/query"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redirectTo answers a REDIRECT with the status code of the redirect, 307 by default.
+func redirectTo(ginCtx *gin.Context, redirect entity.Redirect, location string) {
+	statusCode := redirect.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusTemporaryRedirect
+	}
+
+	ginCtx.Redirect(statusCode, location)
+}
+
+// buildLocation resolves the destination of a REDIRECT answer for the current request:
+// placeholders are expanded first, then the path, query and UTM parameters are
+// carried over as configured.
+func buildLocation(ginCtx *gin.Context, redirect entity.Redirect, destination string) string {
+	path := passthroughPath(ginCtx, redirect)
+	query := incomingQuery(ginCtx, redirect)
+
+	location := entity.ExpandDestination(destination, path, query, ginCtx.Request.Host)
+
+	passthrough := redirect.Passthrough
+	if (passthrough == nil || (!passthrough.Path && passthrough.Query == querytype.NONE)) && redirect.UTM == nil {
+		return location
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	if passthrough != nil && passthrough.Path && path != "" {
+		unescaped, err := url.PathUnescape(path)
+		if err == nil {
+			escaped := strings.TrimSuffix(parsed.EscapedPath(), "/") + path
+			parsed.Path = strings.TrimSuffix(parsed.Path, "/") + unescaped
+			parsed.RawPath = escaped
+		}
+	}
+
+	if passthrough != nil {
+		switch passthrough.Query {
+		case querytype.FORWARD:
+			parsed.RawQuery = query
+
+		case querytype.MERGE:
+			if query != "" {
+				values := parsed.Query()
+				incoming, _ := url.ParseQuery(query)
+				for name, value := range incoming {
+					values[name] = value
+				}
+				parsed.RawQuery = values.Encode()
+			}
+		}
+	}
+
+	if redirect.UTM != nil {
+		values := parsed.Query()
+		changed := false
+		for _, parameter := range redirect.UTM.Parameters() {
+			if !values.Has(parameter[0]) {
+				values.Set(parameter[0], parameter[1])
+				changed = true
+			}
+		}
+		if changed {
+			parsed.RawQuery = values.Encode()
+		}
+	}
+
+	return parsed.String()
+}
+
+// passthroughPath returns the escaped request path left after the part matched by the
+// redirect: the remainder of a PREFIX rule, the whole path of a REGEX rule, and nothing
+// for EXACT rules and short links.
+func passthroughPath(ginCtx *gin.Context, redirect entity.Redirect) string {
+	path := ginCtx.Request.URL.EscapedPath()
+
+	if code, ok := shortCodeFromPath(ginCtx.Request.URL.Path); ok && code == redirect.ID {
+		return ""
+	}
+
+	switch redirect.Match {
+	case matchtype.EXACT:
+		return ""
+
+	case matchtype.PREFIX:
+		prefix := strings.TrimSuffix(redirect.URI, "*")
+		if prefix != "/" && strings.HasPrefix(path, prefix) {
+			path = path[len(prefix):]
+		}
+	}
+
+	if path == "/" {
+		return ""
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return path
+}
+
+// incomingQuery returns the raw query string of the request, without the "to"
+// parameter of ID-based redirects.
+func incomingQuery(ginCtx *gin.Context, redirect entity.Redirect) string {
+	rawQuery := ginCtx.Request.URL.RawQuery
+	if ginCtx.Query("to") != redirect.ID {
+		return rawQuery
+	}
+
+	values := ginCtx.Request.URL.Query()
+	values.Del("to")
+	return values.Encode()
+}
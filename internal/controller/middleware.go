@@ -2,31 +2,72 @@ package controller
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils"
 	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
 	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/port/service"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/metrics"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const FORMAT_TRACE_STR = "[%.3fms] HTTP %d %s %s %s"
+const (
+	FORMAT_TRACE_STR = "[%.3fms] HTTP %d %s %s %s"
 
+	AUTHORIZATION_HEADER = "X-AUTHORIZATION"
+	BEARER_PREFIX        = "Bearer "
+	BASIC_AUTH_REALM     = `Basic realm="url-management"`
+)
+
+// TraceMiddleware starts the server span of the request, continuing the trace of an
+// incoming traceparent, and the trace map carried by the log lines.
 func TraceMiddleware() gin.HandlerFunc {
 	return func(ginCtx *gin.Context) {
 		ctx := GetContext(ginCtx)
 		requestId := uuid.New().String()
 
+		spanName := ginCtx.Request.Method
+		if route := ginCtx.FullPath(); utils.IsNotEmptyStr(route) {
+			spanName += " " + route
+		}
+
+		ctx = tracing.Extract(ctx, ginCtx.Request.Header)
+		ctx, span := tracing.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", ginCtx.Request.Method),
+			attribute.String("http.route", ginCtx.FullPath()),
+			attribute.String("url.path", ginCtx.Request.URL.Path),
+			attribute.String("server.address", ginCtx.Request.Host),
+			attribute.String("client.address", ginCtx.ClientIP()),
+			attribute.String("request.id", requestId),
+		))
+		defer span.End()
+
 		traceMap := make(map[string]any)
 		traceMap[constants.REQUEST_ID] = requestId
 
 		ctx = context.WithValue(ctx, constants.TRACE_MAP, traceMap)
+		ctx = context.WithValue(ctx, constants.CLIENT_IP, ginCtx.ClientIP())
 		ginCtx.Request = ginCtx.Request.WithContext(ctx)
 		ginCtx.Next()
+
+		statusCode := ginCtx.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
 	}
 }
 
@@ -55,6 +96,85 @@ func LoggingMiddleware() gin.HandlerFunc {
 	}
 }
 
+// MetricsMiddleware counts and times every request by its route pattern rather than
+// its path, so that metrics stay bounded however many URLs are served.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		begin := time.Now()
+
+		ginCtx.Next()
+
+		metrics.ObserveRequest(ginCtx.Request.Method, ginCtx.FullPath(), ginCtx.Writer.Status(), time.Since(begin))
+	}
+}
+
+// AuthenticationMiddleware accepts either an API key sent as a Bearer token in the
+// X-AUTHORIZATION header or the basic auth credentials from the configuration, and
+// stores the authenticated principal in the request context.
+func AuthenticationMiddleware(apiKeyService service.IApiKeyService) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		securityConfig := config.ApplicationConfig.Security
+		if !securityConfig.Enabled {
+			ginCtx.Next()
+			return
+		}
+
+		ctx := GetContext(ginCtx)
+		principal := ""
+
+		authorization, _ := GetHeader(ginCtx, AUTHORIZATION_HEADER, false)
+		if utils.IsNotBlankStr(authorization) {
+			key := strings.TrimSpace(authorization)
+			if len(key) > len(BEARER_PREFIX) && strings.EqualFold(key[:len(BEARER_PREFIX)], BEARER_PREFIX) {
+				key = strings.TrimSpace(key[len(BEARER_PREFIX):])
+			}
+
+			apiKey, errw := apiKeyService.Authenticate(ctx, key)
+			if errw != nil {
+				abortUnauthorized(ctx, ginCtx, errw)
+				return
+			}
+
+			principal = "apikey:" + apiKey.Name
+
+		} else if username, password, ok := ginCtx.Request.BasicAuth(); ok && isValidBasicAuth(username, password) {
+			principal = username
+
+		} else {
+			abortUnauthorized(ctx, ginCtx, &exceptions.WrappedError{
+				BaseError: exceptions.Unauthorized,
+			})
+			return
+		}
+
+		traceObj := ctx.Value(constants.TRACE_MAP)
+		if traceMap, ok := traceObj.(map[string]any); ok {
+			traceMap[constants.USER] = principal
+		}
+
+		ctx = context.WithValue(ctx, constants.PRINCIPAL, principal)
+		ginCtx.Request = ginCtx.Request.WithContext(ctx)
+		ginCtx.Next()
+	}
+}
+
+func isValidBasicAuth(username string, password string) bool {
+	basicAuthConfig := config.ApplicationConfig.Security.BasicAuth
+	if utils.IsEmptyStr(basicAuthConfig.Username) || utils.IsEmptyStr(basicAuthConfig.Password) {
+		return false
+	}
+
+	validUsername := subtle.ConstantTimeCompare([]byte(username), []byte(basicAuthConfig.Username)) == constants.ONE
+	validPassword := subtle.ConstantTimeCompare([]byte(password), []byte(basicAuthConfig.Password)) == constants.ONE
+	return validUsername && validPassword
+}
+
+func abortUnauthorized(ctx context.Context, ginCtx *gin.Context, errw *exceptions.WrappedError) {
+	ginCtx.Header("WWW-Authenticate", BASIC_AUTH_REALM)
+	HandleError(ctx, ginCtx, errw)
+	ginCtx.Abort()
+}
+
 func RecoveryMiddleware(ctx context.Context) gin.HandlerFunc {
 	errorLogWriter := log.NewLogWritter(*log.Error(ctx))
 	return gin.CustomRecoveryWithWriter(errorLogWriter, errorHandleRecovery)
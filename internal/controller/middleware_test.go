@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"bytes"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+	exporter := installTestTracing(t)
+
+	logs := &bytes.Buffer{}
+	logger := zerologlog.Logger
+	zerologlog.Logger = zerolog.New(logs)
+	t.Cleanup(func() {
+		zerologlog.Logger = logger
+	})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(TraceMiddleware())
+	engine.GET("/redirect/:id", func(ginCtx *gin.Context) {
+		log.Info(GetContext(ginCtx)).Msg("handling")
+		ginCtx.Status(http.StatusServiceUnavailable)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/redirect/abc", nil)
+	request.Header.Set(TRACEPARENT_HEADER, TEST_TRACEPARENT)
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	span := findSpan(t, exporter, "GET /redirect/:id")
+
+	t.Run("continues the incoming trace", func(t *testing.T) {
+		if span.SpanKind != trace.SpanKindServer {
+			t.Errorf("expected a server span, got %v", span.SpanKind)
+		}
+		if span.SpanContext.TraceID().String() != TEST_TRACE_ID || span.Parent.SpanID().String() != TEST_PARENT_ID {
+			t.Errorf("expected a child of %s, got trace %s and parent %s", TEST_TRACEPARENT, span.SpanContext.TraceID(), span.Parent.SpanID())
+		}
+		if !span.Parent.IsRemote() {
+			t.Error("expected a remote parent")
+		}
+	})
+
+	t.Run("records the route and status", func(t *testing.T) {
+		if route := spanAttribute(span, "http.route").AsString(); route != "/redirect/:id" {
+			t.Errorf("expected route /redirect/:id, got %q", route)
+		}
+		if status := spanAttribute(span, "http.response.status_code").AsInt64(); status != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", status)
+		}
+		if span.Status.Code != codes.Error {
+			t.Errorf("expected an error status for a 503, got %v", span.Status.Code)
+		}
+		if requestId := spanAttribute(span, "request.id").AsString(); requestId == "" {
+			t.Error("expected the request id")
+		}
+	})
+
+	t.Run("logs the trace and span ids", func(t *testing.T) {
+		line := logs.String()
+		for _, expected := range []string{
+			`"traceId":"` + TEST_TRACE_ID + `"`,
+			`"spanId":"` + span.SpanContext.SpanID().String() + `"`,
+			`"message":"handling"`,
+		} {
+			if !strings.Contains(line, expected) {
+				t.Errorf("expected %s in log %s", expected, line)
+			}
+		}
+	})
+}
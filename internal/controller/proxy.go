@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"bytes"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/metrics"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DEFAULT_MAX_REWRITE_SIZE = 10 << 20
+	PROXY_BUFFER_SIZE        = 32 << 10
+)
+
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Connection":    true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailers":            true,
+}
+
+var proxyBufferPool = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, PROXY_BUFFER_SIZE)
+		return &buffer
+	},
+}
+
+// newProxyClient builds the client shared by every proxied request, so upstream
+// connections are pooled and reused instead of being opened per request.
+func newProxyClient() *http.Client {
+	proxyConfig := config.ApplicationConfig.Proxy
+
+	dialer := &net.Dialer{
+		Timeout:   proxyConfig.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          proxyConfig.MaxIdleConns,
+		MaxIdleConnsPerHost:   proxyConfig.MaxIdleConnsPerHost,
+		IdleConnTimeout:       proxyConfig.IdleConnTimeout,
+		TLSHandshakeTimeout:   proxyConfig.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: proxyConfig.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Transport: &instrumentedTransport{transport: transport},
+	}
+}
+
+// instrumentedTransport times and traces every upstream round trip, including each hop
+// of the redirects the client follows, and counts those failing or answering with a
+// 5xx. The traceparent of the round trip span replaces any the client sent.
+type instrumentedTransport struct {
+	transport http.RoundTripper
+}
+
+func (instrumented *instrumentedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Start(request.Context(), request.Method+" "+request.URL.Host, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", request.Method),
+		attribute.String("server.address", request.URL.Host),
+		attribute.String("url.path", request.URL.Path),
+	))
+	defer span.End()
+
+	request = request.Clone(ctx)
+	tracing.Inject(ctx, request.Header)
+
+	begin := time.Now()
+	response, err := instrumented.transport.RoundTrip(request)
+	failed := err != nil || response.StatusCode >= http.StatusInternalServerError
+	metrics.ObserveUpstream(request.URL.Host, time.Since(begin), failed)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return response, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+	if failed {
+		span.SetStatus(codes.Error, http.StatusText(response.StatusCode))
+	}
+	return response, err
+}
+
+// countingReader counts the bytes read through it into the rewritten bytes metric.
+type countingReader struct {
+	reader io.Reader
+}
+
+func (counting *countingReader) Read(buffer []byte) (int, error) {
+	read, err := counting.reader.Read(buffer)
+	metrics.AddRewrittenBytes(read)
+	return read, err
+}
+
+func maxRewriteSize() int64 {
+	size := config.ApplicationConfig.Proxy.MaxRewriteSize
+	if size <= constants.ZERO {
+		return DEFAULT_MAX_REWRITE_SIZE
+	}
+	return size
+}
+
+// readRewritableBody buffers a body that is about to be rewritten. Bodies larger than
+// the configured max-rewrite-size are not buffered: the second result is then false
+// and the returned reader replays what was read followed by the rest of the body.
+func readRewritableBody(body io.Reader) ([]byte, io.Reader, bool, error) {
+	limit := maxRewriteSize()
+
+	buffered, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if int64(len(buffered)) > limit {
+		return nil, io.MultiReader(bytes.NewReader(buffered), body), false, nil
+	}
+
+	return buffered, nil, true, nil
+}
+
+// streamBody copies an upstream body to the client as it arrives. Event streams and
+// bodies of unknown length are flushed after every read so they reach the client
+// without waiting for the buffer to fill.
+func streamBody(writer gin.ResponseWriter, body io.Reader, flush bool) error {
+	bufferRef := proxyBufferPool.Get().(*[]byte)
+	defer proxyBufferPool.Put(bufferRef)
+	buffer := *bufferRef
+
+	if !flush {
+		_, err := io.CopyBuffer(writer, body, buffer)
+		return err
+	}
+
+	for {
+		read, err := body.Read(buffer)
+		if read > constants.ZERO {
+			if _, writeErr := writer.Write(buffer[:read]); writeErr != nil {
+				return writeErr
+			}
+			writer.Flush()
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func shouldFlush(response *http.Response) bool {
+	contentType := strings.ToLower(response.Header.Get("Content-Type"))
+	return strings.Contains(contentType, "text/event-stream") || response.ContentLength < constants.ZERO
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	ENCODING_GZIP     = "gzip"
+	ENCODING_BROTLI   = "br"
+	ENCODING_ZSTD     = "zstd"
+	ENCODING_IDENTITY = "identity"
+)
+
+// supportedEncodings lists the content encodings the proxy can decode and encode, in
+// order of preference when the client accepts several with the same weight.
+var supportedEncodings = []string{ENCODING_BROTLI, ENCODING_ZSTD, ENCODING_GZIP}
+
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// upstreamAcceptEncoding narrows the Accept-Encoding of the client to the encodings the
+// proxy can decode, so a body passed through untouched is always readable by the client
+// and a body to rewrite is always readable by the proxy.
+func upstreamAcceptEncoding(acceptEncoding string) string {
+	accepted := []string{}
+	for _, encoding := range supportedEncodings {
+		if encodingWeight(acceptEncoding, encoding) > constants.ZERO {
+			accepted = append(accepted, encoding)
+		}
+	}
+
+	if len(accepted) == constants.ZERO {
+		return ENCODING_IDENTITY
+	}
+	return strings.Join(accepted, ", ")
+}
+
+// negotiateEncoding picks the encoding of a rewritten response from the Accept-Encoding
+// of the client, or "" to send it uncompressed.
+func negotiateEncoding(acceptEncoding string) string {
+	selected := ""
+	selectedWeight := 0.0
+
+	for _, encoding := range supportedEncodings {
+		weight := encodingWeight(acceptEncoding, encoding)
+		if weight > selectedWeight {
+			selected = encoding
+			selectedWeight = weight
+		}
+	}
+
+	return selected
+}
+
+// encodingWeight returns the quality value given to an encoding by an Accept-Encoding
+// header, falling back to the "*" wildcard, or 0 when it is not accepted.
+func encodingWeight(acceptEncoding string, encoding string) float64 {
+	wildcard := 0.0
+
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		weight := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+
+		switch name {
+		case encoding:
+			return weight
+		case "*":
+			wildcard = weight
+		}
+	}
+
+	return wildcard
+}
+
+func decodeBody(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", ENCODING_IDENTITY:
+		return io.NopCloser(body), nil
+
+	case ENCODING_GZIP, "x-gzip":
+		return gzip.NewReader(body)
+
+	case ENCODING_BROTLI:
+		return io.NopCloser(brotli.NewReader(body)), nil
+
+	case ENCODING_ZSTD:
+		decoder, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+
+	return nil, errUnsupportedEncoding
+}
+
+func encodeBody(encoding string, writer io.Writer) io.WriteCloser {
+	switch encoding {
+	case ENCODING_GZIP:
+		return gzip.NewWriter(writer)
+
+	case ENCODING_BROTLI:
+		return brotli.NewWriter(writer)
+
+	case ENCODING_ZSTD:
+		encoder, err := zstd.NewWriter(writer)
+		if err == nil {
+			return encoder
+		}
+	}
+
+	return nopWriteCloser{writer}
+}
+
+// hasBody reports whether a response to the request may carry a body at all, so empty
+// responses are never wrapped in an encoder that would still write its framing.
+func hasBody(method string, statusCode int) bool {
+	return method != http.MethodHead && statusCode >= http.StatusOK &&
+		statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
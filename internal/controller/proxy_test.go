@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestProxyClientInjectsTraceparent(t *testing.T) {
+	exporter := installTestTracing(t)
+
+	received := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received <- request.Header.Get(TRACEPARENT_HEADER)
+		writer.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/path", nil)
+	request.Header.Set(TRACEPARENT_HEADER, TEST_TRACEPARENT)
+
+	response, err := newProxyClient().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	parent.End()
+
+	host, _ := url.Parse(upstream.URL)
+	span := findSpan(t, exporter, http.MethodGet+" "+host.Host)
+
+	if header := <-received; header != traceparent(span.SpanContext) {
+		t.Errorf("expected the upstream to receive %s, got %s", traceparent(span.SpanContext), header)
+	}
+	if span.SpanKind != trace.SpanKindClient {
+		t.Errorf("expected a client span, got %v", span.SpanKind)
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected a child of %s, got %s", parent.SpanContext().SpanID(), span.Parent.SpanID())
+	}
+	if status := spanAttribute(span, "http.response.status_code").AsInt64(); status != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", status)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("expected an error status for a 502, got %v", span.Status.Code)
+	}
+}
@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fernandoglatz/url-management/internal/core/entity"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FORMAT_JSON   = "json"
+	FORMAT_NDJSON = "ndjson"
+	FORMAT_CSV    = "csv"
+	FORMAT_YAML   = "yaml"
+
+	MAXIMUM_NDJSON_LINE_SIZE = 1024 * 1024
+)
+
+var formatContentTypes = map[string]string{
+	FORMAT_JSON:   "application/json",
+	FORMAT_NDJSON: "application/x-ndjson",
+	FORMAT_CSV:    "text/csv",
+	FORMAT_YAML:   "application/yaml",
+}
+
+var contentTypeFormats = map[string]string{
+	"application/json":     FORMAT_JSON,
+	"application/x-ndjson": FORMAT_NDJSON,
+	"application/ndjson":   FORMAT_NDJSON,
+	"application/jsonl":    FORMAT_NDJSON,
+	"text/csv":             FORMAT_CSV,
+	"application/yaml":     FORMAT_YAML,
+	"application/x-yaml":   FORMAT_YAML,
+	"text/yaml":            FORMAT_YAML,
+}
+
+var errUnknownFormat = errors.New("unknown format, use one of [json, ndjson, csv, yaml]")
+
+// redirectColumn is a CSV column, one per field of a redirect. Text fields are written
+// as is; lists, objects and numbers are written as JSON.
+type redirectColumn struct {
+	name string
+	text bool
+}
+
+var redirectColumns = buildRedirectColumns()
+
+func buildRedirectColumns() []redirectColumn {
+	textTypes := []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(time.Time{}), reflect.TypeOf(&time.Time{})}
+
+	columns := []redirectColumn{}
+	redirectType := reflect.TypeOf(entity.Redirect{})
+	for index := range redirectType.NumField() {
+		field := redirectType.Field(index)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		text := field.Tag.Get("swaggertype") == "string"
+		for _, textType := range textTypes {
+			text = text || field.Type == textType
+		}
+
+		columns = append(columns, redirectColumn{name: name, text: text})
+	}
+
+	return columns
+}
+
+// resolveFormat picks the format of an import or export, from the format parameter or
+// else the media type of a Content-Type or Accept header, JSON by default.
+func resolveFormat(format string, mediaTypes string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", errUnknownFormat
+		}
+		return format, nil
+	}
+
+	for _, mediaType := range strings.Split(mediaTypes, ",") {
+		parsed, _, err := mime.ParseMediaType(strings.TrimSpace(mediaType))
+		if err != nil {
+			continue
+		}
+		if format, ok := contentTypeFormats[parsed]; ok {
+			return format, nil
+		}
+	}
+
+	return FORMAT_JSON, nil
+}
+
+func decodeRedirects(format string, reader io.Reader) ([]entity.Redirect, error) {
+	redirects := []entity.Redirect{}
+
+	switch format {
+	case FORMAT_JSON:
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			return redirects, err
+		}
+
+		// a single redirect is accepted as well as a list
+		body = bytes.TrimSpace(body)
+		if bytes.HasPrefix(body, []byte("{")) {
+			body = append(append([]byte("["), body...), ']')
+		}
+
+		err = json.Unmarshal(body, &redirects)
+		return redirects, err
+
+	case FORMAT_NDJSON:
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), MAXIMUM_NDJSON_LINE_SIZE)
+
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var redirect entity.Redirect
+			if err := json.Unmarshal(line, &redirect); err != nil {
+				return redirects, err
+			}
+			redirects = append(redirects, redirect)
+		}
+		return redirects, scanner.Err()
+
+	case FORMAT_YAML:
+		var document interface{}
+		if err := yaml.NewDecoder(reader).Decode(&document); err != nil && err != io.EOF {
+			return redirects, err
+		}
+		if _, single := document.(map[string]interface{}); single {
+			document = []interface{}{document}
+		}
+
+		return redirects, convertDocument(document, &redirects)
+
+	case FORMAT_CSV:
+		return decodeRedirectsCSV(reader)
+	}
+
+	return redirects, errUnknownFormat
+}
+
+func decodeRedirectsCSV(reader io.Reader) ([]entity.Redirect, error) {
+	redirects := []entity.Redirect{}
+	csvReader := csv.NewReader(reader)
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return redirects, nil
+	} else if err != nil {
+		return redirects, err
+	}
+
+	columnsByName := make(map[string]redirectColumn)
+	for _, column := range redirectColumns {
+		columnsByName[column.name] = column
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return redirects, nil
+		} else if err != nil {
+			return redirects, err
+		}
+
+		document := make(map[string]interface{})
+		for index, cell := range record {
+			column, known := columnsByName[strings.TrimSpace(header[index])]
+			if !known || cell == "" {
+				continue
+			}
+
+			if column.text {
+				document[column.name] = cell
+				continue
+			}
+
+			var value interface{}
+			if err := json.Unmarshal([]byte(cell), &value); err != nil {
+				return redirects, errors.New("invalid value of column [" + column.name + "]: " + err.Error())
+			}
+			document[column.name] = value
+		}
+
+		var redirect entity.Redirect
+		if err := convertDocument(document, &redirect); err != nil {
+			return redirects, err
+		}
+		redirects = append(redirects, redirect)
+	}
+}
+
+func encodeRedirects(format string, writer io.Writer, redirects []entity.Redirect) error {
+	switch format {
+	case FORMAT_JSON:
+		return json.NewEncoder(writer).Encode(redirects)
+
+	case FORMAT_NDJSON:
+		encoder := json.NewEncoder(writer)
+		for _, redirect := range redirects {
+			if err := encoder.Encode(redirect); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FORMAT_YAML:
+		var document interface{}
+		if err := convertDocument(redirects, &document); err != nil {
+			return err
+		}
+
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+		return encoder.Close()
+
+	case FORMAT_CSV:
+		return encodeRedirectsCSV(writer, redirects)
+	}
+
+	return errUnknownFormat
+}
+
+func encodeRedirectsCSV(writer io.Writer, redirects []entity.Redirect) error {
+	csvWriter := csv.NewWriter(writer)
+
+	header := make([]string, len(redirectColumns))
+	for index, column := range redirectColumns {
+		header[index] = column.name
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, redirect := range redirects {
+		var document map[string]interface{}
+		if err := convertDocument(redirect, &document); err != nil {
+			return err
+		}
+
+		record := make([]string, len(redirectColumns))
+		for index, column := range redirectColumns {
+			value, present := document[column.name]
+			if !present || value == nil {
+				continue
+			}
+
+			if text, isText := value.(string); isText && column.text {
+				record[index] = text
+				continue
+			}
+
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			record[index] = string(encoded)
+		}
+
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// convertDocument converts between redirects and generic documents through their JSON
+// form, so every format shares the JSON field names and enum values.
+func convertDocument(source interface{}, target interface{}) error {
+	encoded, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(encoded, target)
+}
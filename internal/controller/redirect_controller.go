@@ -9,22 +9,27 @@ import (
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
 	"fernandoglatz/url-management/internal/core/common/utils/log"
 	"fernandoglatz/url-management/internal/core/entity"
+	fallbacktype "fernandoglatz/url-management/internal/core/entity/fallback"
 	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
 	"fernandoglatz/url-management/internal/core/model/request"
 	"fernandoglatz/url-management/internal/core/port/service"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/metrics"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"fernandoglatz/url-management/internal/core/common/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/render"
 )
 
 var externalURLPattern = regexp.MustCompile(`url\((['"]?)((?:https?:)?//[^'")\s,]+)(['"]?)\)`)
@@ -55,42 +60,287 @@ var rootRelCSSURLPattern = regexp.MustCompile(`url\((['"]?)(/[^/'")][^'")\s,]*)(
 // navigation slugs stored in JSON keep flowing through the proxy.
 var rootRelJSONValuePattern = regexp.MustCompile(`("[\w-]+":")(/[^/"][^"]*)(")`)
 
+const (
+	STATS_DATE_FORMAT     = "2006-01-02"
+	STATS_DEFAULT_DAYS    = 30
+	STATS_MAXIMUM_DAYS    = 366
+	SEC_FETCH_DEST_HEADER = "Sec-Fetch-Dest"
+	TOTAL_COUNT_HEADER    = "X-Total-Count"
+
+	MAXIMUM_IMPORT_BODY_SIZE = 64 * 1024 * 1024
+)
+
 type RedirectController struct {
-	service service.IRedirectService
+	service          service.IRedirectService
+	analyticsService service.IAnalyticsService
+	loadBalancer     service.ILoadBalancerService
+	splitService     service.ISplitService
+	client           *http.Client
+	tunnels          *tunnelTracker
 }
 
-func NewRedirectController(service service.IRedirectService) *RedirectController {
+func NewRedirectController(service service.IRedirectService, analyticsService service.IAnalyticsService, loadBalancer service.ILoadBalancerService, splitService service.ISplitService) *RedirectController {
 	return &RedirectController{
-		service: service,
+		service:          service,
+		analyticsService: analyticsService,
+		loadBalancer:     loadBalancer,
+		splitService:     splitService,
+		client:           newProxyClient(),
+		tunnels:          newTunnelTracker(),
 	}
 }
 
+// Shutdown waits for the proxied WebSocket connections to end, which the HTTP server
+// doesn't track, cutting them when the context is done.
+func (controller *RedirectController) Shutdown(ctx context.Context) {
+	controller.tunnels.drain(ctx)
+}
+
 // @Tags	redirect
 // @Summary	Get redirects
+// @Param	page			query	int		false "page, starting at 1"
+// @Param	size			query	int		false "page size, up to 100"
+// @Param	sort			query	string	false "comma-separated fields, prefixed with '-' for descending order (id, createdAt, updatedAt, dns, uri, destination, type)"
+// @Param	type			query	string	false "type" Enums(PROXY, REDIRECT, IFRAME)
+// @Param	dns				query	string	false "DNS substring"
+// @Param	destinationHost	query	string	false "destination host substring"
+// @Param	tags			query	[]string	false "tags, all of them must be present"
+// @Param	createdFrom		query	string	false "created at or after (RFC 3339)"
+// @Param	createdTo		query	string	false "created at or before (RFC 3339)"
+// @Param	updatedFrom		query	string	false "updated at or after (RFC 3339)"
+// @Param	updatedTo		query	string	false "updated at or before (RFC 3339)"
 // @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
 // @Success	200	{array}		entity.Redirect
+// @Header	200	{integer}	X-Total-Count	"total number of matching redirects"
 // @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router	/redirect [get]
 func (controller *RedirectController) Get(ginCtx *gin.Context) {
 	ctx := GetContext(ginCtx)
 	log.Info(ctx).Msg("Getting redirects")
 
-	redirects, err := controller.service.GetAll(ctx)
+	var filter request.RedirectFilter
+	err := ginCtx.ShouldBindQuery(&filter)
 	if err != nil {
-		HandleError(ctx, ginCtx, err)
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	redirects, total, errw := controller.service.Find(ctx, filter)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.Header(TOTAL_COUNT_HEADER, strconv.FormatInt(total, 10))
+	ginCtx.JSON(http.StatusOK, redirects)
+}
+
+// @Tags	redirect
+// @Summary	Import redirects
+// @Description	Creates or updates redirects in bulk. Nothing is written when any item conflicts or on a dry run; the report lists the action of every item.
+// @Param	format		query	string	false "format, defaults to the Content-Type" Enums(json, ndjson, csv, yaml)
+// @Param	upsertBy	query	string	false "match existing redirects by id (default) or by dns and uri" Enums(id, dns)
+// @Param	dryRun		query	bool	false "only report what would be done"
+// @Param	request		body	[]entity.Redirect	true "redirects"
+// @Accept	json,application/x-ndjson,text/csv,application/yaml
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{object}	response.ImportResponse
+// @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	409	{object}	response.ImportResponse
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/import [post]
+func (controller *RedirectController) Import(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+
+	var options request.ImportOptions
+	err := ginCtx.ShouldBindQuery(&options)
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	format, err := resolveFormat(options.Format, ginCtx.ContentType())
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	log.Info(ctx).Msg(fmt.Sprintf("Importing redirects from %s", format))
+
+	body := http.MaxBytesReader(ginCtx.Writer, ginCtx.Request.Body, MAXIMUM_IMPORT_BODY_SIZE)
+	redirects, err := decodeRedirects(format, body)
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "Invalid " + format + " body: " + err.Error(),
+		})
+		return
+	}
+
+	report, errw := controller.service.Import(ctx, redirects, options)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	status := http.StatusOK
+	if !report.DryRun && !report.Applied {
+		status = http.StatusConflict
+	}
+
+	ginCtx.JSON(status, report)
+}
+
+// @Tags	redirect
+// @Summary	Export redirects
+// @Description	Exports every redirect matching the filters, in a format the import accepts.
+// @Param	format			query	string	false "format, defaults to the Accept header" Enums(json, ndjson, csv, yaml)
+// @Param	type			query	string	false "type" Enums(PROXY, REDIRECT, IFRAME)
+// @Param	dns				query	string	false "DNS substring"
+// @Param	destinationHost	query	string	false "destination host substring"
+// @Param	tags			query	[]string	false "tags, all of them must be present"
+// @Produce	json,application/x-ndjson,text/csv,application/yaml
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{array}		entity.Redirect
+// @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/export [get]
+func (controller *RedirectController) Export(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+
+	var filter request.RedirectFilter
+	err := ginCtx.ShouldBindQuery(&filter)
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	format, err := resolveFormat(ginCtx.Query("format"), ginCtx.GetHeader("Accept"))
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	log.Info(ctx).Msg(fmt.Sprintf("Exporting redirects as %s", format))
+
+	redirects, errw := controller.service.Export(ctx, filter)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.Header("Content-Type", formatContentTypes[format]+"; charset=utf-8")
+	ginCtx.Header("Content-Disposition", `attachment; filename="redirects.`+format+`"`)
+	ginCtx.Status(http.StatusOK)
+
+	if err := encodeRedirects(format, ginCtx.Writer, redirects); err != nil {
+		log.Error(ctx).Msg("Error exporting redirects: " + err.Error())
+	}
+}
+
+// @Tags	redirect
+// @Summary	Get trashed redirects
+// @Description	Lists the removed redirects, the most recently removed first, until they are restored or purged
+// @Param	page			query	int		false "page, starting at 1"
+// @Param	size			query	int		false "page size, up to 100"
+// @Param	sort			query	string	false "comma-separated fields, prefixed with '-' for descending order (id, createdAt, updatedAt, deletedAt, dns, uri, destination, type)"
+// @Param	type			query	string	false "type" Enums(PROXY, REDIRECT, IFRAME)
+// @Param	dns				query	string	false "DNS substring"
+// @Param	destinationHost	query	string	false "destination host substring"
+// @Param	tags			query	[]string	false "tags, all of them must be present"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{array}		entity.Redirect
+// @Header	200	{integer}	X-Total-Count	"total number of matching redirects"
+// @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/trash [get]
+func (controller *RedirectController) GetTrash(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	log.Info(ctx).Msg("Getting trashed redirects")
+
+	var filter request.RedirectFilter
+	err := ginCtx.ShouldBindQuery(&filter)
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	redirects, total, errw := controller.service.FindTrash(ctx, filter)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
 		return
 	}
 
+	ginCtx.Header(TOTAL_COUNT_HEADER, strconv.FormatInt(total, 10))
 	ginCtx.JSON(http.StatusOK, redirects)
 }
 
+// @Tags	redirect
+// @Summary	Purge trashed redirect
+// @Description	Deletes a redirect in the trash for good, keeping its history
+// @Param	id		path	string  true "id"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	204
+// @Failure	401	{object}	response.Response
+// @Failure	404	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/trash/{id} [delete]
+func (controller *RedirectController) DeleteTrashId(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	log.Info(ctx).Msg(fmt.Sprintf("Purging redirect %s", id))
+
+	errw := controller.service.Purge(ctx, id)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+	} else {
+		ginCtx.Status(http.StatusNoContent)
+	}
+}
+
 // @Tags	redirect
 // @Summary	Get redirect
 // @Param	id		path	string  true "id"
 // @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
 // @Success	200	{object}	entity.Redirect
+// @Header	200	{string}	ETag	"version of the redirect, for If-Match"
 // @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router	/redirect/{id} [get]
 func (controller *RedirectController) GetId(ginCtx *gin.Context) {
@@ -105,17 +355,24 @@ func (controller *RedirectController) GetId(ginCtx *gin.Context) {
 		return
 	}
 
+	setETag(ginCtx, redirect)
 	ginCtx.JSON(http.StatusOK, redirect)
 }
 
 // @Tags	redirect
 // @Summary	Update redirect
-// @Param	id		path	string  true "id"
-// @Param	request	body	request.RedirectRequest true "body"
+// @Param	id			path	string  true "id"
+// @Param	If-Match	header	string	false "only update if the redirect still has this ETag"
+// @Param	request		body	request.RedirectRequest true "body"
 // @Accept	json
 // @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
 // @Success	200	{object}	entity.Redirect
+// @Header	200	{string}	ETag	"version of the redirect, for If-Match"
 // @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	412	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router		/redirect/{id} [post]
 func (controller *RedirectController) Post(ginCtx *gin.Context) {
@@ -128,8 +385,12 @@ func (controller *RedirectController) Post(ginCtx *gin.Context) {
 // @Param	request	body	request.RedirectRequest true "body"
 // @Accept	json
 // @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
 // @Success	200	{object}	entity.Redirect
+// @Header	200	{string}	ETag	"version of the redirect, for If-Match"
 // @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router		/redirect [put]
 func (controller *RedirectController) Put(ginCtx *gin.Context) {
@@ -138,12 +399,18 @@ func (controller *RedirectController) Put(ginCtx *gin.Context) {
 
 // @Tags	redirect
 // @Summary	Update redirect
-// @Param	id		path	string  true "id"
-// @Param	request	body	request.RedirectRequest true "body"
+// @Param	id			path	string  true "id"
+// @Param	If-Match	header	string	false "only update if the redirect still has this ETag"
+// @Param	request		body	request.RedirectRequest true "body"
 // @Accept	json
 // @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
 // @Success	200	{object}	entity.Redirect
+// @Header	200	{string}	ETag	"version of the redirect, for If-Match"
 // @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	412	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router		/redirect/{id} [put]
 func (controller *RedirectController) PutId(ginCtx *gin.Context) {
@@ -153,10 +420,16 @@ func (controller *RedirectController) PutId(ginCtx *gin.Context) {
 
 // @Tags	redirect
 // @Summary	Delete redirect
-// @Param	id		path	string  true "id"
+// @Description	Moves a redirect to the trash, from where it can be restored until it's purged
+// @Param	id			path	string  true "id"
+// @Param	If-Match	header	string	false "only delete if the redirect still has this ETag"
 // @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
 // @Success	204
 // @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	412	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router	/redirect/{id} [delete]
 func (controller *RedirectController) DeleteId(ginCtx *gin.Context) {
@@ -171,6 +444,12 @@ func (controller *RedirectController) DeleteId(ginCtx *gin.Context) {
 		return
 	}
 
+	err = checkIfMatch(ginCtx, redirect, true)
+	if err != nil {
+		HandleError(ctx, ginCtx, err)
+		return
+	}
+
 	err = controller.service.Remove(ctx, redirect)
 	if err != nil {
 		HandleError(ctx, ginCtx, err)
@@ -179,6 +458,187 @@ func (controller *RedirectController) DeleteId(ginCtx *gin.Context) {
 	}
 }
 
+// @Tags	redirect
+// @Summary	Get redirect statistics
+// @Param	id		path	string  true "id"
+// @Param	from	query	string  false "first day (YYYY-MM-DD), defaults to 30 days ago"
+// @Param	to		query	string  false "last day (YYYY-MM-DD), defaults to today"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{object}	entity.ClickStats
+// @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/{id}/stats [get]
+func (controller *RedirectController) GetIdStats(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	log.Info(ctx).Msg(fmt.Sprintf("Getting statistics of redirect %s", id))
+
+	redirect, errw := controller.service.Get(ctx, id)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	location, _ := time.LoadLocation(utils.GetTimezone())
+	to := time.Now().In(location)
+	from := to.AddDate(0, 0, 1-STATS_DEFAULT_DAYS)
+
+	if value := ginCtx.Query("to"); utils.IsNotEmptyStr(value) {
+		parsed, err := time.ParseInLocation(STATS_DATE_FORMAT, value, location)
+		if err != nil {
+			HandleError(ctx, ginCtx, &exceptions.WrappedError{
+				BaseError: exceptions.InvalidParameter,
+				Message:   "Invalid [to] date, expected YYYY-MM-DD",
+			})
+			return
+		}
+		to = parsed
+		from = to.AddDate(0, 0, 1-STATS_DEFAULT_DAYS)
+	}
+
+	if value := ginCtx.Query("from"); utils.IsNotEmptyStr(value) {
+		parsed, err := time.ParseInLocation(STATS_DATE_FORMAT, value, location)
+		if err != nil {
+			HandleError(ctx, ginCtx, &exceptions.WrappedError{
+				BaseError: exceptions.InvalidParameter,
+				Message:   "Invalid [from] date, expected YYYY-MM-DD",
+			})
+			return
+		}
+		from = parsed
+	}
+
+	if from.After(to) || to.Sub(from) > STATS_MAXIMUM_DAYS*24*time.Hour {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   fmt.Sprintf("Invalid period, [from] must be before [to] and span at most %d days", STATS_MAXIMUM_DAYS),
+		})
+		return
+	}
+
+	stats, errw := controller.analyticsService.GetStats(ctx, redirect.ID, from, to)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, stats)
+}
+
+// @Tags	redirect
+// @Summary	Get redirect history
+// @Description	Lists the revisions of a redirect, newest first, including after it was removed
+// @Param	id		path	string	true "id"
+// @Param	page	query	int		false "page, starting at 1"
+// @Param	size	query	int		false "page size, up to 100"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{array}		entity.Revision
+// @Header	200	{integer}	X-Total-Count	"total number of revisions"
+// @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/{id}/history [get]
+func (controller *RedirectController) GetIdHistory(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	log.Info(ctx).Msg(fmt.Sprintf("Getting history of redirect %s", id))
+
+	var pagination request.Pagination
+	err := ginCtx.ShouldBindQuery(&pagination)
+	if err != nil {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Error:     err,
+		})
+		return
+	}
+
+	revisions, total, errw := controller.service.GetHistory(ctx, id, pagination)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.Header(TOTAL_COUNT_HEADER, strconv.FormatInt(total, 10))
+	ginCtx.JSON(http.StatusOK, revisions)
+}
+
+// @Tags	redirect
+// @Summary	Roll back redirect
+// @Description	Restores a redirect to its state after a revision, re-creating it if it was removed
+// @Param	id			path	string	true "id"
+// @Param	revision	path	int		true "revision"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{object}	entity.Redirect
+// @Failure	400	{object}	response.Response
+// @Failure	401	{object}	response.Response
+// @Failure	404	{object}	response.Response
+// @Failure	409	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/{id}/rollback/{revision} [post]
+func (controller *RedirectController) PostIdRollback(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	revision, err := strconv.ParseInt(ginCtx.Param("revision"), 10, 64)
+	if err != nil || revision < constants.ONE {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "Invalid revision [" + ginCtx.Param("revision") + "]",
+		})
+		return
+	}
+
+	log.Info(ctx).Msg(fmt.Sprintf("Rolling back redirect %s to revision %d", id, revision))
+
+	redirect, errw := controller.service.Rollback(ctx, id, revision)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	setETag(ginCtx, redirect)
+	ginCtx.JSON(http.StatusOK, redirect)
+}
+
+// @Tags	redirect
+// @Summary	Restore redirect
+// @Description	Takes a redirect out of the trash, unless another redirect has taken its DNS and URI since
+// @Param	id		path	string	true "id"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{object}	entity.Redirect
+// @Failure	401	{object}	response.Response
+// @Failure	404	{object}	response.Response
+// @Failure	409	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/{id}/restore [post]
+func (controller *RedirectController) PostIdRestore(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	log.Info(ctx).Msg(fmt.Sprintf("Restoring redirect %s", id))
+
+	redirect, errw := controller.service.Restore(ctx, id)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	setETag(ginCtx, redirect)
+	ginCtx.JSON(http.StatusOK, redirect)
+}
+
 func (controller *RedirectController) save(ginCtx *gin.Context, id *string, override bool) {
 	ctx := GetContext(ginCtx)
 
@@ -202,6 +662,17 @@ func (controller *RedirectController) save(ginCtx *gin.Context, id *string, over
 			return
 		}
 		redirect.ID = *id
+
+		// the stored version goes along with the redirect, so the repository only
+		// replaces the one that was checked
+		errw = checkIfMatch(ginCtx, redirect, errw == nil)
+		if errw != nil {
+			HandleError(ctx, ginCtx, errw)
+			return
+		}
+
+	} else if utils.IsNotBlankStr(redirectRequest.Slug) {
+		redirect.ID = strings.TrimSpace(redirectRequest.Slug)
 	}
 
 	jsonData, _ := json.Marshal(redirectRequest)
@@ -213,6 +684,7 @@ func (controller *RedirectController) save(ginCtx *gin.Context, id *string, over
 		return
 
 	} else {
+		setETag(ginCtx, redirect)
 		ginCtx.JSON(http.StatusOK, redirect)
 	}
 }
@@ -223,6 +695,7 @@ func (controller *RedirectController) save(ginCtx *gin.Context, id *string, over
 // @Produce	json
 // @Success	307
 // @Failure	400	{object}	response.Response
+// @Failure	410	{object}	response.Response
 // @Failure	500	{object}	response.Response
 // @Router  / [get]
 func (controller *RedirectController) Execute(ginCtx *gin.Context) {
@@ -238,7 +711,7 @@ func (controller *RedirectController) Execute(ginCtx *gin.Context) {
 			return
 		}
 
-		controller.redirect(ctx, ginCtx, redirect)
+		controller.serve(ctx, ginCtx, redirect)
 
 	} else {
 		controller.NoRoute(ginCtx)
@@ -255,33 +728,171 @@ func (controller *RedirectController) NoRoute(ginCtx *gin.Context) {
 		dns = host
 	}
 
-	log.Info(ctx).Msg(fmt.Sprintf("Searching redirect for [%s]", dns))
+	path := ginCtx.Request.URL.Path
+
+	log.Info(ctx).Msg(fmt.Sprintf("Searching redirect for [%s%s]", dns, path))
+
+	redirect, err := controller.service.GetByDNS(ctx, dns, path)
+	if err != nil && err.BaseError == exceptions.RecordNotFound {
+		code, ok := shortCodeFromPath(path)
+		if !ok {
+			return
+		}
+
+		log.Info(ctx).Msg(fmt.Sprintf("Searching redirect for short code [%s]", code))
+		redirect, err = controller.service.Get(ctx, code)
+	}
 
-	redirect, err := controller.service.GetByDNS(ctx, dns)
 	if err == nil {
-		controller.redirect(ctx, ginCtx, redirect)
+		controller.serve(ctx, ginCtx, redirect)
 
 	} else if err.BaseError != exceptions.RecordNotFound {
 		HandleError(ctx, ginCtx, err)
 	}
 }
 
+// shortCodeFromPath extracts the code of a path-style short link, either /{code}
+// or {context-path}/{code}.
+func shortCodeFromPath(path string) (string, bool) {
+	contextPath := config.ApplicationConfig.Server.ContextPath
+	if utils.IsNotEmptyStr(contextPath) && strings.HasPrefix(path, contextPath+"/") {
+		path = path[len(contextPath):]
+	}
+
+	code := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/")
+	if !utils.IsValidShortCode(code) {
+		return "", false
+	}
+
+	return code, true
+}
+
+// serve answers a resolved redirect, honoring its activation window, expiration and
+// click limit before sending the client to the destination.
+func (controller *RedirectController) serve(ctx context.Context, ginCtx *gin.Context, redirect entity.Redirect) {
+	now := time.Now()
+
+	if !redirect.IsActive(now) {
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.RecordNotFound,
+		})
+		return
+	}
+
+	metrics.RecordRedirectHit(redirect.ID)
+
+	expired := redirect.IsExpired(now)
+
+	countsClick := redirect.Type != redirecttype.PROXY || isPageView(ginCtx)
+	if !expired && redirect.MaxClicks > constants.ZERO && countsClick {
+		clicks, err := controller.service.RegisterClick(ctx, redirect)
+		if err != nil {
+			HandleError(ctx, ginCtx, err)
+			return
+		}
+
+		expired = clicks > redirect.MaxClicks
+	}
+
+	if expired {
+		log.Info(ctx).Msg(fmt.Sprintf("Redirect %s expired, serving fallback", redirect.ID))
+		controller.fallback(ctx, ginCtx, resolveFallback(redirect))
+	} else {
+		controller.redirect(ctx, ginCtx, redirect)
+	}
+
+	controller.record(ctx, ginCtx, redirect)
+}
+
+func (controller *RedirectController) fallback(ctx context.Context, ginCtx *gin.Context, fallback entity.Fallback) {
+	switch fallback.Type {
+	case fallbacktype.REDIRECT:
+		ginCtx.Redirect(http.StatusFound, fallback.URL)
+
+	case fallbacktype.PAGE:
+		ginCtx.Data(http.StatusGone, "text/html; charset=utf-8", []byte(fallback.Page))
+
+	default:
+		HandleError(ctx, ginCtx, &exceptions.WrappedError{
+			BaseError: exceptions.RedirectExpired,
+		})
+	}
+}
+
+// resolveFallback returns the fallback of the redirect, or the configured default when
+// it has none.
+func resolveFallback(redirect entity.Redirect) entity.Fallback {
+	if redirect.Fallback != nil {
+		return *redirect.Fallback
+	}
+
+	fallbackConfig := config.ApplicationConfig.Redirect.Expiration.Fallback
+	fallbackType, ok := fallbacktype.Parse(strings.ToUpper(fallbackConfig.Type))
+	if !ok || (fallbackType == fallbacktype.REDIRECT && utils.IsBlankStr(fallbackConfig.URL)) {
+		fallbackType = fallbacktype.GONE
+	}
+
+	return entity.Fallback{
+		Type: fallbackType,
+		URL:  fallbackConfig.URL,
+		Page: fallbackConfig.Page,
+	}
+}
+
+// record queues a click for the analytics. Proxied hosts also serve their assets and
+// API calls through here, so for PROXY only page navigations count as hits.
+func (controller *RedirectController) record(ctx context.Context, ginCtx *gin.Context, redirect entity.Redirect) {
+	if redirect.Type == redirecttype.PROXY && !isPageView(ginCtx) {
+		return
+	}
+
+	controller.analyticsService.Record(ctx, entity.Click{
+		RedirectID: redirect.ID,
+		Timestamp:  time.Now(),
+		Referer:    ginCtx.Request.Referer(),
+		UserAgent:  ginCtx.Request.UserAgent(),
+		Status:     ginCtx.Writer.Status(),
+		IP:         ginCtx.ClientIP(),
+	})
+}
+
+func isPageView(ginCtx *gin.Context) bool {
+	if ginCtx.Request.Method != http.MethodGet || ginCtx.IsWebsocket() {
+		return false
+	}
+
+	if fetchDest := ginCtx.GetHeader(SEC_FETCH_DEST_HEADER); utils.IsNotEmptyStr(fetchDest) {
+		return fetchDest == "document"
+	}
+
+	return strings.Contains(ginCtx.GetHeader("Accept"), "text/html")
+}
+
 func (controller *RedirectController) redirect(ctx context.Context, ginCtx *gin.Context, redirect entity.Redirect) {
+	redirect = controller.service.ResolveRule(redirect, request.Visitor{
+		IP:     ginCtx.ClientIP(),
+		Header: ginCtx.Request.Header,
+		Query:  ginCtx.Request.URL.Query(),
+		Time:   time.Now(),
+	})
+
 	switch redirect.Type {
 	case redirecttype.PROXY:
-		urlDestination, err := url.Parse(redirect.Destination)
+		upstream, release := controller.loadBalancer.Select(redirect, balancingKey(ginCtx, redirect))
+		success := false
+		defer func() { release(success) }()
+
+		urlDestination, err := url.Parse(upstream)
 		if err != nil {
 			HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
 			return
 		}
 
 		if strings.EqualFold(ginCtx.Request.Header.Get("Upgrade"), "websocket") {
-			controller.proxyWebSocket(ginCtx, urlDestination)
+			success = controller.proxyWebSocket(ginCtx, urlDestination, redirect.Headers)
 			return
 		}
 
-		client := &http.Client{}
-
 		uri := ginCtx.Request.RequestURI
 		body := ginCtx.Request.Body
 		method := ginCtx.Request.Method
@@ -312,22 +923,14 @@ func (controller *RedirectController) redirect(ctx context.Context, ginCtx *gin.
 
 		defer body.Close()
 
-		hopByHopHeaders := map[string]bool{
-			"Connection":          true,
-			"Keep-Alive":          true,
-			"Proxy-Connection":    true,
-			"Transfer-Encoding":   true,
-			"Upgrade":             true,
-			"Proxy-Authenticate":  true,
-			"Proxy-Authorization": true,
-			"Te":                  true,
-			"Trailers":            true,
-			"Accept-Encoding":     true,
+		request, err := http.NewRequestWithContext(ginCtx.Request.Context(), method, destination+uri, body)
+		if err != nil {
+			HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
+			return
 		}
 
-		request, _ := http.NewRequest(method, destination+uri, body)
 		for key, values := range headers {
-			if hopByHopHeaders[key] {
+			if hopByHopHeaders[key] || key == "Accept-Encoding" {
 				continue
 			}
 			newValues := make([]string, 0, len(values))
@@ -338,20 +941,17 @@ func (controller *RedirectController) redirect(ctx context.Context, ginCtx *gin.
 
 			request.Header[key] = newValues
 		}
+		request.Header.Set("Accept-Encoding", upstreamAcceptEncoding(ginCtx.GetHeader("Accept-Encoding")))
+		applyRequestHeaderRules(request.Header, redirect.Headers)
 
-		response, err := client.Do(request)
-		if err != nil {
-			HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
-			return
-		}
-
-		responseBody, err := io.ReadAll(response.Body)
+		response, err := controller.client.Do(request)
 		if err != nil {
 			HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
 			return
 		}
 
 		defer response.Body.Close()
+		success = response.StatusCode < http.StatusInternalServerError
 
 		contentType := response.Header.Get("Content-Type")
 
@@ -364,16 +964,10 @@ func (controller *RedirectController) redirect(ctx context.Context, ginCtx *gin.
 		}
 		crossHost := !strings.EqualFold(finalHost, destinationDomain)
 
-		stripResponseHeaders := map[string]bool{
-			"X-Frame-Options":                     true,
-			"Content-Security-Policy":             true,
-			"Content-Security-Policy-Report-Only": true,
-			"Strict-Transport-Security":           true,
-			"Content-Encoding":                    true,
-		}
+		stripResponseHeaders := strippedResponseHeaders(redirect.Headers)
 
 		for key, values := range response.Header {
-			if stripResponseHeaders[key] {
+			if stripResponseHeaders[key] || hopByHopHeaders[key] {
 				continue
 			}
 			for _, value := range values {
@@ -390,22 +984,32 @@ func (controller *RedirectController) redirect(ctx context.Context, ginCtx *gin.
 				ginCtx.Writer.Header().Add(key, newValue)
 			}
 		}
+		applyResponseHeaderRules(ginCtx.Writer.Header(), redirect.Headers)
 
-		if isTextBasedContent(contentType) {
-			responseBodyStr := string(responseBody)
-			responseBodyStr = strings.ReplaceAll(responseBodyStr, destinationDomain, domain)
-			responseBodyStr = strings.ReplaceAll(responseBodyStr, destinationRootDomain, domain)
-			responseBodyStr = rewriteExternalURLs(responseBodyStr, proxyBase, proxyHost, destinationRootDomain)
-			if crossHost {
-				responseBodyStr = rewriteRootRelativeAssets(responseBodyStr, proxyBase, finalHost)
-			}
-			responseBody = []byte(responseBodyStr)
+		rewriter := &urlRewriter{
+			proxyBase:             proxyBase,
+			proxyHost:             proxyHost,
+			destinationDomain:     destinationDomain,
+			destinationRootDomain: destinationRootDomain,
+		}
+		if crossHost {
+			rewriter.assetHost = finalHost
 		}
 
-		ginCtx.Render(response.StatusCode, render.Data{
-			ContentType: contentType,
-			Data:        responseBody,
-		})
+		switch {
+		case useTokenizerRewriter() && (isHTMLContent(contentType) || isCSSContent(contentType)):
+			controller.streamRewritten(ctx, ginCtx, response, rewriter.forContentType(contentType))
+
+		case isTextBasedContent(contentType):
+			controller.streamRewritten(ctx, ginCtx, response, func(reader io.Reader, writer io.Writer) error {
+				return rewriteBuffered(ctx, reader, writer, func(content string) string {
+					return rewriter.rewriteRegex(content, domain)
+				})
+			})
+
+		default:
+			streamResponse(ctx, ginCtx, response)
+		}
 
 	case redirecttype.IFRAME:
 		destination := redirect.Destination
@@ -424,11 +1028,21 @@ func (controller *RedirectController) redirect(ctx context.Context, ginCtx *gin.
 		ginCtx.String(http.StatusOK, html)
 
 	default:
-		ginCtx.Redirect(http.StatusTemporaryRedirect, redirect.Destination)
+		if len(redirect.Variants) > constants.ZERO {
+			controller.split(ctx, ginCtx, redirect)
+			return
+		}
+
+		upstream, release := controller.loadBalancer.Select(redirect, balancingKey(ginCtx, redirect))
+		release(true)
+
+		redirectTo(ginCtx, redirect, buildLocation(ginCtx, redirect, upstream))
 	}
 }
 
-func (controller *RedirectController) proxyWebSocket(ginCtx *gin.Context, destination *url.URL) {
+// proxyWebSocket tunnels a WebSocket connection to the destination, reporting whether
+// the upstream accepted the upgrade.
+func (controller *RedirectController) proxyWebSocket(ginCtx *gin.Context, destination *url.URL, headerPolicy *entity.HeaderPolicy) bool {
 	ctx := GetContext(ginCtx)
 
 	upstreamHost := destination.Host
@@ -450,8 +1064,9 @@ func (controller *RedirectController) proxyWebSocket(ginCtx *gin.Context, destin
 		upstreamConn, dialErr = net.Dial("tcp", upstreamHost)
 	}
 	if dialErr != nil {
+		metrics.RecordUpstreamError(destination.Host)
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: dialErr})
-		return
+		return false
 	}
 	defer upstreamConn.Close()
 
@@ -490,46 +1105,56 @@ func (controller *RedirectController) proxyWebSocket(ginCtx *gin.Context, destin
 		}
 		upstreamReq.Header[key] = newValues
 	}
+	applyRequestHeaderRules(upstreamReq.Header, headerPolicy)
+	tracing.Inject(ctx, upstreamReq.Header)
 
 	if err := upstreamReq.Write(upstreamConn); err != nil {
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
-		return
+		return false
 	}
 
 	upstreamReader := bufio.NewReader(upstreamConn)
 	upstreamResp, err := http.ReadResponse(upstreamReader, upstreamReq)
 	if err != nil {
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
-		return
+		return false
 	}
 	if upstreamResp.StatusCode != http.StatusSwitchingProtocols {
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{
 			Error: fmt.Errorf("WebSocket upstream returned %d, expected 101", upstreamResp.StatusCode),
 		})
-		return
+		return false
 	}
 
+	applyResponseHeaderRules(upstreamResp.Header, headerPolicy)
+
 	hijacker, ok := ginCtx.Writer.(http.Hijacker)
 	if !ok {
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{
 			Error: fmt.Errorf("response writer does not support hijacking"),
 		})
-		return
+		return true
 	}
 	clientConn, clientBuf, err := hijacker.Hijack()
 	if err != nil {
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
-		return
+		return true
 	}
 	defer clientConn.Close()
 
+	if !controller.tunnels.open(clientConn, upstreamConn) {
+		log.Warn(ctx).Msg("Refusing WebSocket connection, the server is shutting down")
+		return true
+	}
+	defer controller.tunnels.close(clientConn, upstreamConn)
+
 	if err := upstreamResp.Write(clientBuf); err != nil {
 		log.Error(ctx).Msg("Failed to write WebSocket 101 response to client: " + err.Error())
-		return
+		return true
 	}
 	if err := clientBuf.Flush(); err != nil {
 		log.Error(ctx).Msg("Failed to flush WebSocket 101 response to client: " + err.Error())
-		return
+		return true
 	}
 
 	var wg sync.WaitGroup
@@ -548,6 +1173,7 @@ func (controller *RedirectController) proxyWebSocket(ginCtx *gin.Context, destin
 	}()
 
 	wg.Wait()
+	return true
 }
 
 func (controller *RedirectController) CDN(ginCtx *gin.Context) {
@@ -608,8 +1234,7 @@ func buildCDNPathTarget(requestURI string) (string, bool) {
 }
 
 func (controller *RedirectController) serveCDN(ctx context.Context, ginCtx *gin.Context, targetURL string) {
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodGet, targetURL, nil)
+	req, err := http.NewRequestWithContext(ginCtx.Request.Context(), http.MethodGet, targetURL, nil)
 	if err != nil {
 		HandleError(ctx, ginCtx, &exceptions.WrappedError{Error: err})
 		return
@@ -628,8 +1253,9 @@ func (controller *RedirectController) serveCDN(ctx context.Context, ginCtx *gin.
 	if parsed, parseErr := url.Parse(targetURL); parseErr == nil && parsed.Host != "" {
 		req.Header.Set("Referer", parsed.Scheme+"://"+parsed.Host+"/")
 	}
+	req.Header.Set("Accept-Encoding", upstreamAcceptEncoding(ginCtx.GetHeader("Accept-Encoding")))
 
-	response, err := client.Do(req)
+	response, err := controller.client.Do(req)
 	if err != nil {
 		log.Error(ctx).Msg("CDN proxy fetch error for " + targetURL + ": " + err.Error())
 		ginCtx.Status(http.StatusBadGateway)
@@ -637,58 +1263,142 @@ func (controller *RedirectController) serveCDN(ctx context.Context, ginCtx *gin.
 	}
 	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	contentType := response.Header.Get("Content-Type")
+
+	ginCtx.Header("Access-Control-Allow-Origin", "*")
+	if cc := response.Header.Get("Cache-Control"); cc != "" {
+		ginCtx.Header("Cache-Control", cc)
+	}
+
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Vary"} {
+		if value := response.Header.Get(key); utils.IsNotEmptyStr(value) {
+			ginCtx.Header(key, value)
+		}
+	}
+
+	proxyBase, proxyHost := proxyOrigin(ginCtx)
+
+	// Root-relative URLs inside a resource fetched through /__cdnp/<targetHost> belong
+	// to that host, but a browser resolves them against the proxy origin root and 404s.
+	// Re-point them at /__cdnp/<targetHost> so the whole page (and its fonts, icons,
+	// and navigation) keeps resolving through the proxy.
+	targetHost := ""
+	if parsed, parseErr := url.Parse(targetURL); parseErr == nil {
+		targetHost = parsed.Host
+	}
+
+	switch {
+	case useTokenizerRewriter() && (isHTMLContent(contentType) || isCSSContent(contentType)):
+		rewriter := &urlRewriter{
+			proxyBase:      proxyBase,
+			proxyHost:      proxyHost,
+			assetHost:      targetHost,
+			rewriteAnchors: true,
+		}
+		controller.streamRewritten(ctx, ginCtx, response, rewriter.forContentType(contentType))
+
+	case isHTMLContent(contentType) || isCSSContent(contentType) || isManifestContent(contentType):
+		controller.streamRewritten(ctx, ginCtx, response, func(reader io.Reader, writer io.Writer) error {
+			return rewriteBuffered(ctx, reader, writer, func(content string) string {
+				switch {
+				case isHTMLContent(contentType):
+					return rewriteCDNHTML(content, proxyBase, proxyHost, targetHost)
+				case isCSSContent(contentType):
+					return rewriteCDNCSS(content, proxyBase, proxyHost, targetHost)
+				default:
+					return rewriteCDNManifest(content, proxyBase, targetHost)
+				}
+			})
+		})
+
+	default:
+		streamResponse(ctx, ginCtx, response)
+	}
+}
+
+// streamRewritten decodes the upstream body, passes it through rewrite and encodes the
+// result as the client accepts. Bodies in an encoding the proxy can't decode are sent
+// untouched.
+func (controller *RedirectController) streamRewritten(ctx context.Context, ginCtx *gin.Context, response *http.Response, rewrite func(io.Reader, io.Writer) error) {
+	if !hasBody(ginCtx.Request.Method, response.StatusCode) {
+		streamResponse(ctx, ginCtx, response)
+		return
+	}
+
+	body, err := decodeBody(response.Header.Get("Content-Encoding"), response.Body)
 	if err != nil {
-		log.Error(ctx).Msg("CDN proxy read error for " + targetURL + ": " + err.Error())
-		ginCtx.Status(http.StatusBadGateway)
+		log.Warn(ctx).Msg("Not rewriting response of " + response.Request.URL.String() + ": " + err.Error())
+		streamResponse(ctx, ginCtx, response)
 		return
 	}
+	defer body.Close()
 
-	contentType := response.Header.Get("Content-Type")
+	header := ginCtx.Writer.Header()
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Add("Vary", "Accept-Encoding")
 
-	if isHTMLContent(contentType) || isCSSContent(contentType) || isManifestContent(contentType) {
-		scheme := "http"
-		if ginCtx.Request.TLS != nil {
-			scheme = "https"
-		}
-		if proto := ginCtx.GetHeader("X-Forwarded-Proto"); proto != "" {
-			scheme = proto
-		}
-		host := ginCtx.Request.Host
-		proxyBase := scheme + "://" + host
-		proxyHost, _, _ := net.SplitHostPort(host)
-		if proxyHost == "" {
-			proxyHost = host
-		}
+	encoding := negotiateEncoding(ginCtx.GetHeader("Accept-Encoding"))
+	if utils.IsNotEmptyStr(encoding) {
+		header.Set("Content-Encoding", encoding)
+	}
 
-		// Root-relative URLs inside a resource fetched through /__cdnp/<targetHost> belong
-		// to that host, but a browser resolves them against the proxy origin root and 404s.
-		// Re-point them at /__cdnp/<targetHost> so the whole page (and its fonts, icons,
-		// and navigation) keeps resolving through the proxy.
-		targetHost := ""
-		if parsed, parseErr := url.Parse(targetURL); parseErr == nil {
-			targetHost = parsed.Host
-		}
+	ginCtx.Status(response.StatusCode)
 
-		switch {
-		case isHTMLContent(contentType):
-			body = []byte(rewriteCDNHTML(string(body), proxyBase, proxyHost, targetHost))
-		case isCSSContent(contentType):
-			body = []byte(rewriteCDNCSS(string(body), proxyBase, proxyHost, targetHost))
-		case isManifestContent(contentType):
-			body = []byte(rewriteCDNManifest(string(body), proxyBase, targetHost))
-		}
+	writer := encodeBody(encoding, ginCtx.Writer)
+	err = rewrite(&countingReader{reader: body}, writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
 	}
 
-	ginCtx.Header("Access-Control-Allow-Origin", "*")
-	if cc := response.Header.Get("Cache-Control"); cc != "" {
-		ginCtx.Header("Cache-Control", cc)
+	if err != nil {
+		log.Warn(ctx).Msg("Error rewriting response of " + response.Request.URL.String() + ": " + err.Error())
+	}
+}
+
+// streamResponse sends the upstream body as is, in whatever encoding it came.
+func streamResponse(ctx context.Context, ginCtx *gin.Context, response *http.Response) {
+	ginCtx.Status(response.StatusCode)
+	if err := streamBody(ginCtx.Writer, response.Body, shouldFlush(response)); err != nil {
+		log.Warn(ctx).Msg("Error streaming response of " + response.Request.URL.String() + ": " + err.Error())
 	}
+}
 
-	ginCtx.Render(response.StatusCode, render.Data{
-		ContentType: contentType,
-		Data:        body,
-	})
+// rewriteBuffered applies a whole-body rewrite, buffering the body up to the configured
+// max-rewrite-size. Larger bodies are copied unmodified.
+func rewriteBuffered(ctx context.Context, reader io.Reader, writer io.Writer, rewrite func(string) string) error {
+	body, remainingBody, ok, err := readRewritableBody(reader)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		log.Warn(ctx).Msg("Response exceeds max-rewrite-size, sending it unmodified")
+		_, err = io.Copy(writer, remainingBody)
+		return err
+	}
+
+	_, err = io.WriteString(writer, rewrite(string(body)))
+	return err
+}
+
+// proxyOrigin returns the base URL and hostname the client used to reach the proxy.
+func proxyOrigin(ginCtx *gin.Context) (string, string) {
+	scheme := "http"
+	if ginCtx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ginCtx.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := ginCtx.Request.Host
+	proxyHost, _, _ := net.SplitHostPort(host)
+	if proxyHost == "" {
+		proxyHost = host
+	}
+
+	return scheme + "://" + host, proxyHost
 }
 
 func rewriteExternalURLs(content, proxyBase, proxyHost, destinationRootDomain string) string {
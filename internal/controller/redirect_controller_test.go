@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProxyWebSocketInjectsTraceparent(t *testing.T) {
+	exporter := installTestTracing(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		request, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			received <- ""
+			return
+		}
+		received <- request.Header.Get(TRACEPARENT_HEADER)
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+	}()
+
+	destination, _ := url.Parse("ws://" + listener.Addr().String() + "/socket")
+	controller := &RedirectController{tunnels: newTunnelTracker()}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(TraceMiddleware())
+	engine.GET("/socket", func(ginCtx *gin.Context) {
+		controller.proxyWebSocket(ginCtx, destination, nil)
+	})
+
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/socket", nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set(TRACEPARENT_HEADER, TEST_TRACEPARENT)
+	if err := request.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := http.ReadResponse(bufio.NewReader(conn), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", response.StatusCode)
+	}
+	header := <-received
+	conn.Close()
+
+	server.Close()
+	span := findSpan(t, exporter, "GET /socket")
+
+	if header != traceparent(span.SpanContext) {
+		t.Errorf("expected the upstream to receive %s, got %s", traceparent(span.SpanContext), header)
+	}
+	if span.SpanContext.TraceID().String() != TEST_TRACE_ID {
+		t.Errorf("expected the incoming trace %s, got %s", TEST_TRACE_ID, span.SpanContext.TraceID())
+	}
+}
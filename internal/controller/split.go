@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SPLIT_COOKIE_PREFIX          = "um_split_"
+	SPLIT_ASSIGNMENT_PARAM       = "assignment"
+	DEFAULT_SPLIT_COOKIE_MAX_AGE = 30 * 24 * 60 * 60
+)
+
+func splitCookieName(redirect entity.Redirect) string {
+	return SPLIT_COOKIE_PREFIX + redirect.ID
+}
+
+// split sends the visitor to its variant of an A/B split redirect, keeping the signed
+// assignment in a cookie so the same variant is served on the next visits. The
+// assignment is also passed to the destination when split.assignment-param is set,
+// for landing pages on another domain to send it back with their conversions.
+func (controller *RedirectController) split(ctx context.Context, ginCtx *gin.Context, redirect entity.Redirect) {
+	assignment, _ := ginCtx.Cookie(splitCookieName(redirect))
+	variant, assignment := controller.splitService.Assign(redirect, assignment)
+
+	maxAge := int(config.ApplicationConfig.Split.CookieMaxAge.Seconds())
+	if maxAge <= 0 {
+		maxAge = DEFAULT_SPLIT_COOKIE_MAX_AGE
+	}
+
+	ginCtx.SetSameSite(http.SameSiteLaxMode)
+	ginCtx.SetCookie(splitCookieName(redirect), assignment, maxAge, "/", "", ginCtx.Request.TLS != nil, true)
+
+	if errw := controller.splitService.RecordHit(ctx, redirect, variant); errw != nil {
+		log.Error(ctx).Msg(fmt.Sprintf("Error recording hit of variant %s of redirect %s: %s", variant.Name, redirect.ID, errw.GetMessage()))
+	}
+
+	location := buildLocation(ginCtx, redirect, variant.Destination)
+	location = withAssignment(location, config.ApplicationConfig.Split.AssignmentParam, assignment)
+
+	redirectTo(ginCtx, redirect, location)
+}
+
+func withAssignment(location string, param string, assignment string) string {
+	if param == "" {
+		return location
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	query := parsed.Query()
+	query.Set(param, assignment)
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
+
+// @Tags	redirect
+// @Summary	Record a conversion of an A/B split redirect
+// @Description	Counts a conversion for the variant assigned to the visitor by its cookie, or by the signed assignment passed to the destination when the cookie isn't sent
+// @Param	id			path	string	true	"id"
+// @Param	assignment	query	string	false	"signed assignment, used when the visitor has no assignment cookie"
+// @Success	204
+// @Failure	400	{object}	response.Response
+// @Failure	404	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/conversion/{id} [post]
+func (controller *RedirectController) Conversion(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	redirect, errw := controller.service.Get(ctx, id)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	assignment, err := ginCtx.Cookie(splitCookieName(redirect))
+	if err != nil {
+		assignment = ginCtx.Query(SPLIT_ASSIGNMENT_PARAM)
+	}
+
+	errw = controller.splitService.RecordConversion(ctx, redirect, assignment)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.Status(http.StatusNoContent)
+}
+
+// @Tags	redirect
+// @Summary	Compare the variants of an A/B split redirect
+// @Param	id	path	string	true	"id"
+// @Produce	json
+// @Security	BasicAuth
+// @Security	Bearer
+// @Success	200	{array}		entity.VariantStats
+// @Failure	401	{object}	response.Response
+// @Failure	404	{object}	response.Response
+// @Failure	500	{object}	response.Response
+// @Router	/redirect/{id}/variants [get]
+func (controller *RedirectController) GetIdVariants(ginCtx *gin.Context) {
+	ctx := GetContext(ginCtx)
+	id := ginCtx.Param("id")
+
+	log.Info(ctx).Msg(fmt.Sprintf("Getting variants of redirect %s", id))
+
+	redirect, errw := controller.service.Get(ctx, id)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	stats, errw := controller.splitService.GetStats(ctx, redirect)
+	if errw != nil {
+		HandleError(ctx, ginCtx, errw)
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TEST_TRACE_ID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+	TEST_PARENT_ID     = "00f067aa0ba902b7"
+	TEST_TRACEPARENT   = "00-" + TEST_TRACE_ID + "-" + TEST_PARENT_ID + "-01"
+	TRACEPARENT_HEADER = "traceparent"
+)
+
+// installTestTracing exports the spans to memory, as a tracing.enabled setup would
+// export them through OTLP.
+func installTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	ctx := context.Background()
+	if _, err := tracing.Setup(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := tracing.Install(ctx, sdktrace.WithSyncer(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		provider.Shutdown(ctx)
+	})
+
+	return exporter
+}
+
+func findSpan(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("no span %q among %d", name, len(exporter.GetSpans()))
+	return tracetest.SpanStub{}
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, keyValue := range span.Attributes {
+		if keyValue.Key == key {
+			return keyValue.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func traceparent(spanContext trace.SpanContext) string {
+	return "00-" + spanContext.TraceID().String() + "-" + spanContext.SpanID().String() + "-01"
+}
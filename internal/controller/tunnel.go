@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/infrastructure/metrics"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// tunnelTracker keeps the connections of the proxied WebSocket tunnels, which the HTTP
+// server forgets about once they are hijacked, so that a shutdown can wait for them.
+type tunnelTracker struct {
+	mutex   sync.Mutex
+	tunnels sync.WaitGroup
+	conns   map[net.Conn]bool
+	count   int
+	closing bool
+}
+
+func newTunnelTracker() *tunnelTracker {
+	return &tunnelTracker{
+		conns: make(map[net.Conn]bool),
+	}
+}
+
+// open registers the connections of a new tunnel, refusing it once draining started.
+func (tracker *tunnelTracker) open(conns ...net.Conn) bool {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.closing {
+		return false
+	}
+
+	for _, conn := range conns {
+		tracker.conns[conn] = true
+	}
+	tracker.count++
+	metrics.TunnelOpened()
+	tracker.tunnels.Add(constants.ONE)
+	return true
+}
+
+func (tracker *tunnelTracker) close(conns ...net.Conn) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	for _, conn := range conns {
+		delete(tracker.conns, conn)
+	}
+	tracker.count--
+	metrics.TunnelClosed()
+	tracker.tunnels.Done()
+}
+
+// drain waits for the open tunnels to end by themselves, and cuts those still open
+// when the context is done.
+func (tracker *tunnelTracker) drain(ctx context.Context) {
+	tracker.mutex.Lock()
+	tracker.closing = true
+	tracker.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		tracker.tunnels.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	tracker.mutex.Lock()
+	for conn := range tracker.conns {
+		conn.Close()
+	}
+	open := tracker.count
+	tracker.mutex.Unlock()
+
+	<-done
+	log.Warn(ctx).Msg("Cut " + strconv.Itoa(open) + " WebSocket connections still open at the shutdown deadline")
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	CDN_PATH_PREFIX = "/__cdnp/"
+	REWRITER_REGEX  = "REGEX"
+)
+
+// urlRewriter maps the URLs referenced by proxied content to their proxy equivalents.
+// Links to the destination (or its root domain) are served by the proxy itself, other
+// hosts go through /__cdnp/<host>. Root-relative references are only touched when
+// assetHost is set, i.e. when they belong to a host other than the proxied one.
+type urlRewriter struct {
+	proxyBase             string
+	proxyHost             string
+	destinationDomain     string
+	destinationRootDomain string
+
+	// assetHost re-points root-relative assets at /__cdnp/<assetHost>
+	assetHost string
+	// rewriteAnchors also re-points root-relative navigation (<a>, <form>) at assetHost
+	rewriteAnchors bool
+}
+
+// useTokenizerRewriter reports whether HTML and CSS are rewritten by the tokenizers,
+// the default, or by the legacy regular expressions.
+func useTokenizerRewriter() bool {
+	return !strings.EqualFold(config.ApplicationConfig.Proxy.Rewriter, REWRITER_REGEX)
+}
+
+// forContentType returns the streaming rewrite of a content type, CSS or else HTML.
+func (rewriter *urlRewriter) forContentType(contentType string) func(io.Reader, io.Writer) error {
+	if isCSSContent(contentType) {
+		return rewriter.rewriteCSS
+	}
+	return rewriter.rewriteHTML
+}
+
+func (rewriter *urlRewriter) cdnPrefix(host string) string {
+	return rewriter.proxyBase + CDN_PATH_PREFIX + host
+}
+
+// rewriteURL returns the proxy URL of an absolute or root-relative reference, or the
+// value unchanged when it keeps resolving correctly as is. Navigation references are
+// left on the proxy host unless rewriteAnchors is set.
+func (rewriter *urlRewriter) rewriteURL(value string, navigation bool) string {
+	trimmed := strings.TrimSpace(value)
+
+	switch {
+	case isAbsoluteURL(trimmed):
+		return rewriter.rewriteAbsoluteURL(trimmed)
+
+	case strings.HasPrefix(trimmed, "/"):
+		if rewriter.assetHost == "" || (navigation && !rewriter.rewriteAnchors) {
+			return value
+		}
+		return rewriter.cdnPrefix(rewriter.assetHost) + trimmed
+	}
+
+	return value
+}
+
+func (rewriter *urlRewriter) rewriteAbsoluteURL(value string) string {
+	normalizedURL := value
+	if strings.HasPrefix(value, "//") {
+		normalizedURL = "https:" + value
+	}
+
+	parsed, err := url.Parse(normalizedURL)
+	if err != nil || parsed.Host == "" {
+		return value
+	}
+
+	hostname := strings.ToLower(parsed.Hostname())
+
+	// Reject non-hostname paths like //api/endpoint (no dot = not an external host)
+	if !strings.Contains(hostname, ".") {
+		return value
+	}
+
+	requestURI := parsed.RequestURI()
+	if parsed.Fragment != "" {
+		requestURI += "#" + parsed.EscapedFragment()
+	}
+
+	if hostname == rewriter.proxyHost || hostname == rewriter.destinationDomain || hostname == rewriter.destinationRootDomain {
+		return rewriter.proxyBase + requestURI
+	}
+
+	return rewriter.cdnPrefix(hostname) + requestURI
+}
+
+// rewriteRegex is the legacy rewrite of a whole body, which the tokenizers replace
+// for HTML and CSS: the destination domains are replaced everywhere, and URLs are
+// rewritten wherever the regular expressions find them.
+func (rewriter *urlRewriter) rewriteRegex(content string, domain string) string {
+	content = strings.ReplaceAll(content, rewriter.destinationDomain, domain)
+	content = strings.ReplaceAll(content, rewriter.destinationRootDomain, domain)
+	content = rewriteExternalURLs(content, rewriter.proxyBase, rewriter.proxyHost, rewriter.destinationRootDomain)
+	if rewriter.assetHost != "" {
+		content = rewriteRootRelativeAssets(content, rewriter.proxyBase, rewriter.assetHost)
+	}
+	return content
+}
+
+// rewriteSrcset rewrites every URL of a srcset, a comma-separated list of
+// "URL descriptor" candidates.
+func (rewriter *urlRewriter) rewriteSrcset(value string) string {
+	candidates := strings.Split(value, ",")
+	for index, candidate := range candidates {
+		trimmed := strings.TrimLeft(candidate, " \t\n")
+		lead := candidate[:len(candidate)-len(trimmed)]
+
+		candidateURL, descriptor, _ := strings.Cut(trimmed, " ")
+		rewritten := rewriter.rewriteURL(candidateURL, false)
+		if descriptor != "" {
+			rewritten += " " + descriptor
+		}
+		candidates[index] = lead + rewritten
+	}
+
+	return strings.Join(candidates, ",")
+}
+
+// rewriteRefresh rewrites the URL of a meta refresh, e.g. "5; url=https://...".
+func (rewriter *urlRewriter) rewriteRefresh(value string) string {
+	delay, target, found := strings.Cut(value, ";")
+	if !found {
+		return value
+	}
+
+	target = strings.TrimSpace(target)
+	if len(target) < 4 || !strings.EqualFold(target[:4], "url=") {
+		return value
+	}
+
+	refreshURL := strings.TrimSpace(target[4:])
+	quote := ""
+	if len(refreshURL) > 1 && (refreshURL[0] == '\'' || refreshURL[0] == '"') && refreshURL[len(refreshURL)-1] == refreshURL[0] {
+		quote = refreshURL[:1]
+		refreshURL = refreshURL[1 : len(refreshURL)-1]
+	}
+
+	return delay + "; " + target[:4] + quote + rewriter.rewriteURL(refreshURL, true) + quote
+}
+
+// rewriteScript rewrites the quoted URLs of inline scripts and JSON data, which have
+// no tokenizer: this reuses the patterns of the regex rewriter.
+func (rewriter *urlRewriter) rewriteScript(content string) string {
+	content = quotedURLPattern.ReplaceAllStringFunc(content, func(match string) string {
+		sub := quotedURLPattern.FindStringSubmatch(match)
+		if len(sub) < 5 || sub[2] != sub[4] || strings.HasPrefix(sub[1], "xmlns") {
+			return match
+		}
+
+		newURL := rewriter.rewriteAbsoluteURL(sub[3])
+		if newURL == sub[3] {
+			return match
+		}
+
+		prefix := ""
+		if sub[1] != "" {
+			prefix = sub[1] + "="
+		}
+		return prefix + sub[2] + newURL + sub[4]
+	})
+
+	content = mfeRemoteURLPattern.ReplaceAllStringFunc(content, func(match string) string {
+		sub := mfeRemoteURLPattern.FindStringSubmatch(match)
+		if len(sub) < 3 {
+			return match
+		}
+		return sub[1] + rewriter.rewriteAbsoluteURL(sub[2])
+	})
+
+	if rewriter.assetHost != "" {
+		prefix := rewriter.cdnPrefix(rewriter.assetHost)
+		content = rootRelJSONValuePattern.ReplaceAllStringFunc(content, func(match string) string {
+			sub := rootRelJSONValuePattern.FindStringSubmatch(match)
+			if len(sub) < 4 || !looksLikeAssetPath(sub[2]) {
+				return match
+			}
+			return sub[1] + prefix + sub[2] + sub[3]
+		})
+	}
+
+	return content
+}
+
+func isAbsoluteURL(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") ||
+		(strings.HasPrefix(value, "//") && len(value) > 2 && value[2] != '/')
+}
@@ -10,39 +10,81 @@ import (
 	"fernandoglatz/url-management/internal/infrastructure/repository"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func Setup(ctx context.Context, engine *gin.Engine) {
+// Setup registers the routes and starts the background services, which run until the
+// context is done. It returns the function that drains and flushes what they still
+// hold, to call once the server stopped taking requests.
+func Setup(ctx context.Context, engine *gin.Engine) func(context.Context) {
 	log.Info(ctx).Msg("Configuring routes")
 
 	contextPath := config.ApplicationConfig.Server.ContextPath
 	router := engine.Group(contextPath)
 
-	redirectRepository := repository.NewRedirectCacheRepository(repository.NewRedirectRepository())
-	redirectService := service.NewRedirectService(redirectRepository)
-	redirectController := controller.NewRedirectController(redirectService)
+	revisionRepository := repository.NewRevisionRepository()
+	redirectRepository := repository.NewRedirectCacheRepository(repository.NewRedirectHistoryRepository(repository.NewRedirectRepository(), revisionRepository))
+	redirectService := service.NewRedirectService(redirectRepository, revisionRepository)
+	redirectService.StartExpirationSweeper(ctx)
+	redirectService.StartTrashPurger(ctx)
+	analyticsService := service.NewAnalyticsService(repository.NewClickRepository(), repository.NewSecretRepository())
+	analyticsService.Start(ctx)
+	loadBalancerService := service.NewLoadBalancerService()
+	loadBalancerService.Start(ctx)
+	splitService := service.NewSplitService(repository.NewVariantRepository())
+	redirectController := controller.NewRedirectController(redirectService, analyticsService, loadBalancerService, splitService)
 
-	healthController := controller.NewHealthController()
+	apiKeyService := service.NewApiKeyService(repository.NewApiKeyRepository())
+	authenticationController := controller.NewAuthenticationController(apiKeyService)
+	authenticationMiddleware := controller.AuthenticationMiddleware(apiKeyService)
+
+	healthController := controller.NewHealthController(service.NewHealthService())
 
 	engine.GET("", redirectController.Execute)
 	engine.GET("/__cdn", redirectController.CDN)
 	engine.GET("/__cdnp/*fullpath", redirectController.CDNPath)
 	router.GET("", redirectController.Execute)
 	router.GET("/", redirectController.Execute) //swagger
-	routerRedirect := router.Group("/redirect")
+	routerRedirect := router.Group("/redirect", authenticationMiddleware)
 	routerRedirect.GET("", redirectController.Get)
+	routerRedirect.GET("export", redirectController.Export)
+	routerRedirect.POST("import", redirectController.Import)
+	routerRedirect.GET("trash", redirectController.GetTrash)
+	routerRedirect.DELETE("trash/:id", redirectController.DeleteTrashId)
 	routerRedirect.GET(":id", redirectController.GetId)
 	routerRedirect.PUT("", redirectController.Put)
 	routerRedirect.PUT(":id", redirectController.PutId)
 	routerRedirect.POST(":id", redirectController.Post)
 	routerRedirect.DELETE(":id", redirectController.DeleteId)
+	routerRedirect.GET(":id/stats", redirectController.GetIdStats)
+	routerRedirect.GET(":id/variants", redirectController.GetIdVariants)
+	routerRedirect.GET(":id/history", redirectController.GetIdHistory)
+	routerRedirect.POST(":id/rollback/:revision", redirectController.PostIdRollback)
+	routerRedirect.POST(":id/restore", redirectController.PostIdRestore)
+	routerAuthentication := router.Group("/authentication", authenticationMiddleware)
+	routerAuthentication.GET("", authenticationController.Get)
+	routerAuthentication.PUT("", authenticationController.Put)
+	routerAuthentication.DELETE(":id", authenticationController.DeleteId)
+
+	router.GET("/conversion/:id", redirectController.Conversion)
+	router.POST("/conversion/:id", redirectController.Conversion)
 
 	router.GET("/health", healthController.Health)
+	router.GET("/health/live", healthController.Live)
+	router.GET("/health/ready", healthController.Ready)
+	if config.ApplicationConfig.Metrics.Enabled {
+		router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
 	router.GET("/swagger-ui/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	engine.NoRoute(redirectController.NoRoute)
 
 	log.Info(ctx).Msg("Routes configured")
+
+	return func(ctx context.Context) {
+		redirectController.Shutdown(ctx)
+		analyticsService.Stop(ctx)
+	}
 }
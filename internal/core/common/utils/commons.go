@@ -4,6 +4,7 @@ import (
 	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"os"
 	"strings"
+	"time"
 )
 
 func IsEmptyStr(value string) bool {
@@ -26,6 +27,13 @@ func GetTimezone() string {
 	return os.Getenv("TZ")
 }
 
+// StartOfDay truncates a time to midnight in the configured timezone.
+func StartOfDay(value time.Time) time.Time {
+	location, _ := time.LoadLocation(GetTimezone())
+	local := value.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
 // ExtractRootDomain extracts the main domain from a hostname, handling common subdomains and public suffixes.
 // For example, www.strava.com -> strava.com, api.example.co.uk -> example.co.uk
 func ExtractRootDomain(hostname string) string {
@@ -58,3 +66,19 @@ func ExtractRootDomain(hostname string) string {
 	}
 	return lastTwo
 }
+
+// DNSCandidates lists the DNS entries that can serve a hostname, from the most
+// to the least specific: the hostname itself, wildcards for each parent domain
+// and the catch-all "*". For example, a.b.example.com ->
+// [a.b.example.com *.b.example.com *.example.com *.com *]
+func DNSCandidates(hostname string) []string {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	candidates := []string{hostname}
+
+	parts := strings.Split(hostname, ".")
+	for index := 1; index < len(parts); index++ {
+		candidates = append(candidates, "*."+strings.Join(parts[index:], "."))
+	}
+
+	return append(candidates, "*")
+}
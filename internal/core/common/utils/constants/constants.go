@@ -4,6 +4,10 @@ type ContextKey string
 
 const (
 	TRACE_MAP ContextKey = "TRACE-MAP"
+	PRINCIPAL ContextKey = "PRINCIPAL"
+	CLIENT_IP ContextKey = "CLIENT-IP"
+
+	RESTORED_REVISION ContextKey = "RESTORED-REVISION"
 
 	LOGGING_LEVEL = "LOGGING_LEVEL"
 	PROFILE       = "PROFILE"
@@ -11,6 +15,7 @@ const (
 
 	ID         string = "id"
 	REQUEST_ID string = "REQUEST-ID"
+	USER       string = "USER"
 
 	ZERO = 0
 	ONE  = 1
@@ -17,6 +17,34 @@ var (
 		Code:    "INVALID_JSON",
 		Message: "Invalid JSON.",
 	}
+	RecordAlreadyExists = BaseError{
+		Code:    "RECORD_ALREADY_EXISTS",
+		Message: "Record already exists.",
+	}
+	RedirectExpired = BaseError{
+		Code:    "REDIRECT_EXPIRED",
+		Message: "Redirect expired.",
+	}
+	Conflict = BaseError{
+		Code:    "CONFLICT",
+		Message: "Conflicts with an existing record.",
+	}
+	ValidationError = BaseError{
+		Code:    "VALIDATION_ERROR",
+		Message: "Invalid fields.",
+	}
+	InvalidParameter = BaseError{
+		Code:    "INVALID_PARAMETER",
+		Message: "Invalid parameter.",
+	}
+	PreconditionFailed = BaseError{
+		Code:    "PRECONDITION_FAILED",
+		Message: "Record was modified by another request.",
+	}
+	Unauthorized = BaseError{
+		Code:    "UNAUTHORIZED",
+		Message: "Missing or invalid credentials.",
+	}
 )
 
 type WrappedError struct {
@@ -24,6 +52,12 @@ type WrappedError struct {
 	Message   string
 	Code      string
 	BaseError BaseError
+	Details   []FieldError
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type BaseError struct {
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var GeoIPDatabase geoIPDatabaseType
+
+type geoIPDatabaseType struct {
+	Reader *maxminddb.Reader
+}
+
+type geoIPCountryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// ConnectToGeoIP opens the offline MaxMind database (GeoLite2/GeoIP2 Country or City)
+// configured in data.geoip.database. It is optional: without it countries are unknown.
+func ConnectToGeoIP(ctx context.Context) error {
+	databasePath := config.ApplicationConfig.Data.GeoIP.Database
+	if IsBlankStr(databasePath) {
+		log.Info(ctx).Msg("GeoIP database not configured")
+		return nil
+	}
+
+	log.Info(ctx).Msg("Opening GeoIP database " + databasePath)
+
+	reader, err := maxminddb.Open(databasePath)
+	if err != nil {
+		return err
+	}
+
+	GeoIPDatabase = geoIPDatabaseType{
+		Reader: reader,
+	}
+
+	log.Info(ctx).Msg("GeoIP database opened")
+	return nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 country code of an IP address, or an empty
+// string when it is unknown.
+func (geoIPDatabase *geoIPDatabaseType) Country(ip string) string {
+	if geoIPDatabase.Reader == nil {
+		return ""
+	}
+
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return ""
+	}
+
+	var record geoIPCountryRecord
+	err := geoIPDatabase.Reader.Lookup(parsedIP, &record)
+	if err != nil {
+		return ""
+	}
+
+	return record.Country.ISOCode
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var currentLevel = TRACE
@@ -21,6 +22,7 @@ var currentFormat = format.TEXT
 
 type LoggerEvent struct {
 	traceMap map[string]any
+	span     trace.SpanContext
 	event    *zerolog.Event
 	caller   string
 	level    Level
@@ -149,6 +151,11 @@ func (loggerEvent *LoggerEvent) Msg(msg string) {
 			}
 		}
 
+		if loggerEvent.span.IsValid() {
+			event = event.Str("traceId", loggerEvent.span.TraceID().String())
+			event = event.Str("spanId", loggerEvent.span.SpanID().String())
+		}
+
 		if caller != "" {
 			event = event.Str("caller", caller)
 		}
@@ -191,6 +198,7 @@ func CreateLoggerEvent(ctx context.Context, event *zerolog.Event, level Level) *
 	if traceObj != nil {
 		loggerEvent.traceMap = traceObj.(map[string]any)
 	}
+	loggerEvent.span = trace.SpanContextFromContext(ctx)
 
 	_, file, no, ok := runtime.Caller(DEFAULT_CALLER_LEVEL)
 	if ok {
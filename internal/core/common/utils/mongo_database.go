@@ -2,14 +2,18 @@ package utils
 
 import (
 	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"fernandoglatz/url-management/internal/core/common/utils/log"
 	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/mongodb"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 var MongoDatabase mongoDatabaseType
@@ -17,6 +21,14 @@ var MongoDatabase mongoDatabaseType
 type mongoDatabaseType struct {
 	Client      *mongo.Database
 	collections map[string]*mongo.Collection
+
+	// MigrationVersion is the version the migrations were brought to at startup
+	MigrationVersion int
+}
+
+type migrationState struct {
+	Version int  `bson:"version"`
+	Dirty   bool `bson:"dirty"`
 }
 
 func ConnectToMongoDB(ctx context.Context) error {
@@ -26,7 +38,7 @@ func ConnectToMongoDB(ctx context.Context) error {
 	uri := config.Uri
 	databaseName := config.Database
 
-	clientOptions := options.Client().ApplyURI(uri)
+	clientOptions := options.Client().ApplyURI(uri).SetMonitor(tracing.NewMongoMonitor())
 	client, err := mongo.Connect(context.Background(), clientOptions)
 	if err != nil {
 		return err
@@ -56,14 +68,47 @@ func ConnectToMongoDB(ctx context.Context) error {
 		return err
 	}
 
+	migrationVersion, _, err := migrations.Version()
+	if err != nil {
+		return err
+	}
+
 	MongoDatabase = mongoDatabaseType{
-		Client:      client.Database(databaseName),
-		collections: make(map[string]*mongo.Collection),
+		Client:           client.Database(databaseName),
+		collections:      make(map[string]*mongo.Collection),
+		MigrationVersion: int(migrationVersion),
 	}
 
 	return nil
 }
 
+func DisconnectFromMongoDB(ctx context.Context) error {
+	if MongoDatabase.Client == nil {
+		return nil
+	}
+
+	log.Info(ctx).Msg("Disconnecting from MongoDB")
+	return MongoDatabase.Client.Client().Disconnect(ctx)
+}
+
+func (mongoDatabase mongoDatabaseType) Ping(ctx context.Context) error {
+	return mongoDatabase.Client.Client().Ping(ctx, readpref.Primary())
+}
+
+// GetMigrationState returns the version the migrations are currently at, and whether
+// the last one failed halfway.
+func (mongoDatabase mongoDatabaseType) GetMigrationState(ctx context.Context) (int, bool, error) {
+	var state migrationState
+
+	collection := mongoDatabase.GetCollection(mongodb.DefaultMigrationsCollection)
+	err := collection.FindOne(ctx, bson.M{}).Decode(&state)
+	if err != nil {
+		return constants.ZERO, false, err
+	}
+
+	return state.Version, state.Dirty, nil
+}
+
 func (mongoDatabase mongoDatabaseType) GetCollection(collectionName string) *mongo.Collection {
 	collection := mongoDatabase.collections[collectionName]
 
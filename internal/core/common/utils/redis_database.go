@@ -31,8 +31,7 @@ func ConnectToRedis(ctx context.Context) error {
 		Client: client,
 	}
 
-	cmd := client.Conn().Ping(ctx)
-	err := cmd.Err()
+	err := client.Ping(ctx).Err()
 
 	if err == nil {
 		log.Info(ctx).Msg("Redis connected")
@@ -43,6 +42,19 @@ func ConnectToRedis(ctx context.Context) error {
 	return nil
 }
 
+func DisconnectFromRedis(ctx context.Context) error {
+	if RedisDatabase.Client == nil {
+		return nil
+	}
+
+	log.Info(ctx).Msg("Disconnecting from Redis")
+	return RedisDatabase.Client.Close()
+}
+
+func (redisDatabase *redisDatabaseType) Ping(ctx context.Context) error {
+	return redisDatabase.Client.Ping(ctx).Err()
+}
+
 func (redisDatabase *redisDatabaseType) Get(ctx context.Context, key string) *redis.StringCmd {
 	return redisDatabase.Client.Get(ctx, key)
 }
@@ -51,8 +63,8 @@ func (redisDatabase *redisDatabaseType) Set(ctx context.Context, key string, val
 	return redisDatabase.Client.Set(ctx, key, value, expiration).Err()
 }
 
-func (redisDatabase *redisDatabaseType) Del(ctx context.Context, key string) error {
-	return redisDatabase.Client.Del(ctx, key).Err()
+func (redisDatabase *redisDatabaseType) Del(ctx context.Context, keys ...string) error {
+	return redisDatabase.Client.Del(ctx, keys...).Err()
 }
 
 func (redisDatabase *redisDatabaseType) GetStruct(ctx context.Context, key string, value interface{}) error {
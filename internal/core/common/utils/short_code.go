@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"regexp"
+)
+
+const BASE62_ALPHABET = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var base62AlphabetLength = big.NewInt(int64(len(BASE62_ALPHABET)))
+var shortCodePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_-]{0,62}[A-Za-z0-9])?$`)
+
+// GenerateShortCode returns a random base62 code of the given length.
+func GenerateShortCode(length int) (string, error) {
+	code := make([]byte, length)
+
+	for index := range code {
+		position, err := rand.Int(rand.Reader, base62AlphabetLength)
+		if err != nil {
+			return "", err
+		}
+		code[index] = BASE62_ALPHABET[position.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// IsValidShortCode reports whether a value can be used as a short code: 1 to 64
+// letters, digits, "-" or "_", starting and ending with a letter or digit.
+func IsValidShortCode(code string) bool {
+	return shortCodePattern.MatchString(code)
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+type ApiKey struct {
+	ID        string    `json:"id" bson:"id"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	Name      string     `json:"name" bson:"name"`
+	Prefix    string     `json:"prefix" bson:"prefix"`
+	Hash      string     `json:"-" bson:"hash"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
+
+func (apiKey ApiKey) IsRevoked() bool {
+	return apiKey.RevokedAt != nil
+}
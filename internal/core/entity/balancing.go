@@ -0,0 +1,30 @@
+package entity
+
+import (
+	balancingtype "fernandoglatz/url-management/internal/core/entity/balancing"
+)
+
+// Upstream is one of the weighted destinations a redirect balances between.
+type Upstream struct {
+	URL    string `json:"url" bson:"url"`
+	Weight int    `json:"weight,omitempty" bson:"weight,omitempty"`
+}
+
+// Balancing selects how the upstreams of a redirect share its traffic.
+type Balancing struct {
+	Strategy balancingtype.Type `json:"strategy" bson:"strategy" swaggertype:"string" enums:"ROUND_ROBIN,WEIGHTED_RANDOM,LEAST_CONNECTIONS,IP_HASH,COOKIE_HASH"`
+	// Cookie holding the sticky session of COOKIE_HASH, defaults to the configured one
+	Cookie string `json:"cookie,omitempty" bson:"cookie,omitempty"`
+	// HealthCheckPath enables active health checks, requesting it on every upstream
+	HealthCheckPath string `json:"healthCheckPath,omitempty" bson:"healthCheckPath,omitempty"`
+}
+
+// GetUpstreams returns the upstreams of a redirect, its single destination when it
+// has no list.
+func (redirect Redirect) GetUpstreams() []Upstream {
+	if len(redirect.Upstreams) > 0 {
+		return redirect.Upstreams
+	}
+
+	return []Upstream{{URL: redirect.Destination, Weight: 1}}
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Certificate is an entry of the ACME certificate cache: account keys, certificates
+// with their private keys and pending challenge tokens, stored under the key given by
+// the ACME client so every replica shares them.
+type Certificate struct {
+	Key       string    `bson:"key"`
+	Data      []byte    `bson:"data"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+type Click struct {
+	RedirectID string    `json:"redirectId" bson:"redirectId"`
+	Timestamp  time.Time `json:"timestamp" bson:"timestamp"`
+
+	Referer   string `json:"referer,omitempty" bson:"referer,omitempty"`
+	UserAgent string `json:"userAgent,omitempty" bson:"userAgent,omitempty"`
+	IPHash    string `json:"ipHash,omitempty" bson:"ipHash,omitempty"`
+	Country   string `json:"country,omitempty" bson:"country,omitempty"`
+	Status    int    `json:"status" bson:"status"`
+
+	IP string `json:"-" bson:"-"`
+}
+
+type ClickCounter struct {
+	RedirectID string    `json:"-" bson:"redirectId"`
+	Day        time.Time `json:"day" bson:"day"`
+	Count      int64     `json:"count" bson:"count"`
+}
+
+type RefererCounter struct {
+	Referer string `json:"referer" bson:"_id"`
+	Count   int64  `json:"count" bson:"count"`
+}
+
+type ClickStats struct {
+	// Total is the number of clicks from the first to the last day of the series
+	Total       int64            `json:"total"`
+	Series      []ClickCounter   `json:"series"`
+	TopReferers []RefererCounter `json:"topReferers"`
+}
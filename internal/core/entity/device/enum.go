@@ -0,0 +1,87 @@
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+type Type int
+
+const (
+	IOS     Type = iota
+	ANDROID Type = iota
+	MOBILE  Type = iota
+	TABLET  Type = iota
+	DESKTOP Type = iota
+	BOT     Type = iota
+)
+
+var typeNames = map[Type]string{
+	IOS:     "IOS",
+	ANDROID: "ANDROID",
+	MOBILE:  "MOBILE",
+	TABLET:  "TABLET",
+	DESKTOP: "DESKTOP",
+	BOT:     "BOT",
+}
+
+var typeValues = map[string]Type{
+	"IOS":     IOS,
+	"ANDROID": ANDROID,
+	"MOBILE":  MOBILE,
+	"TABLET":  TABLET,
+	"DESKTOP": DESKTOP,
+	"BOT":     BOT,
+}
+
+func Parse(name string) (Type, bool) {
+	val, ok := typeValues[name]
+	return val, ok
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(t))
+}
+
+func (t Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	val, ok := typeValues[name]
+	if !ok {
+		return fmt.Errorf("unknown device: %s", name)
+	}
+	*t = val
+	return nil
+}
+
+func (t Type) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.String, bsoncore.AppendString(nil, t.String()), nil
+}
+
+func (t *Type) UnmarshalBSONValue(bt bsontype.Type, data []byte) error {
+	if bt != bsontype.String {
+		return fmt.Errorf("expected BSON string, got %v", bt)
+	}
+	str, _, ok := bsoncore.ReadString(data)
+	if !ok {
+		return fmt.Errorf("failed to read BSON string for device")
+	}
+	val, ok := typeValues[str]
+	if !ok {
+		return fmt.Errorf("unknown device: %s", str)
+	}
+	*t = val
+	return nil
+}
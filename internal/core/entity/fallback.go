@@ -0,0 +1,12 @@
+package entity
+
+import (
+	fallbacktype "fernandoglatz/url-management/internal/core/entity/fallback"
+)
+
+// Fallback is what an expired redirect answers instead of its destination.
+type Fallback struct {
+	Type fallbacktype.Type `json:"type" bson:"type" swaggertype:"string" enums:"GONE,REDIRECT,PAGE"`
+	URL  string            `json:"url,omitempty" bson:"url,omitempty"`
+	Page string            `json:"page,omitempty" bson:"page,omitempty"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+// HeaderPolicy customizes the headers a PROXY redirect exchanges with its destination.
+type HeaderPolicy struct {
+	Request  HeaderRules `json:"request" bson:"request"`
+	Response HeaderRules `json:"response" bson:"response"`
+
+	// CSP, X-Frame-Options and HSTS are stripped from responses unless disabled here
+	StripCSP          *bool `json:"stripCsp,omitempty" bson:"stripCsp,omitempty"`
+	StripFrameOptions *bool `json:"stripFrameOptions,omitempty" bson:"stripFrameOptions,omitempty"`
+	StripHSTS         *bool `json:"stripHsts,omitempty" bson:"stripHsts,omitempty"`
+}
+
+// HeaderRules are applied in order: Remove, then Set (replacing any value), then Add.
+// Keep only applies to responses and exempts headers from being stripped.
+type HeaderRules struct {
+	Add    map[string]string `json:"add,omitempty" bson:"add,omitempty"`
+	Set    map[string]string `json:"set,omitempty" bson:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty" bson:"remove,omitempty"`
+	Keep   []string          `json:"keep,omitempty" bson:"keep,omitempty"`
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	querytype "fernandoglatz/url-management/internal/core/entity/query"
+	"strings"
+)
+
+const (
+	PLACEHOLDER_PATH  = "{path}"
+	PLACEHOLDER_QUERY = "{query}"
+	PLACEHOLDER_HOST  = "{host}"
+)
+
+// Passthrough carries parts of the incoming request over to the destination of
+// REDIRECT answers.
+type Passthrough struct {
+	// Path appends the request path, less the prefix matched by the redirect
+	Path bool `json:"path,omitempty" bson:"path,omitempty"`
+	// Query forwards the request query string instead of the destination one, or merges
+	// both with the request values taking precedence
+	Query querytype.Type `json:"query" bson:"query" swaggertype:"string" enums:"NONE,FORWARD,MERGE"`
+}
+
+// UTM parameters added to the destination of REDIRECT answers, unless already present.
+type UTM struct {
+	Source   string `json:"source,omitempty" bson:"source,omitempty"`
+	Medium   string `json:"medium,omitempty" bson:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty" bson:"campaign,omitempty"`
+	Term     string `json:"term,omitempty" bson:"term,omitempty"`
+	Content  string `json:"content,omitempty" bson:"content,omitempty"`
+}
+
+// Parameters returns the UTM query parameters that are set, in their usual order.
+func (utm UTM) Parameters() [][2]string {
+	parameters := [][2]string{}
+	for _, parameter := range [][2]string{
+		{"utm_source", utm.Source},
+		{"utm_medium", utm.Medium},
+		{"utm_campaign", utm.Campaign},
+		{"utm_term", utm.Term},
+		{"utm_content", utm.Content},
+	} {
+		if parameter[1] != "" {
+			parameters = append(parameters, parameter)
+		}
+	}
+
+	return parameters
+}
+
+// ExpandDestination replaces the {path}, {query} and {host} placeholders of a
+// destination with the values of the incoming request.
+func ExpandDestination(destination string, path string, query string, host string) string {
+	if !strings.Contains(destination, "{") {
+		return destination
+	}
+
+	return strings.NewReplacer(PLACEHOLDER_PATH, path, PLACEHOLDER_QUERY, query, PLACEHOLDER_HOST, host).Replace(destination)
+}
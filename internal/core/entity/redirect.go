@@ -3,6 +3,7 @@ package entity
 import (
 	"time"
 
+	matchtype "fernandoglatz/url-management/internal/core/entity/match"
 	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
 )
 
@@ -10,8 +11,56 @@ type Redirect struct {
 	ID        string    `json:"id" bson:"id"`
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	// DeletedAt marks a redirect moved to the trash, ignored until restored or purged
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// Version is incremented on every write, and a write only applies to the version it read
+	Version int64 `json:"version" bson:"version"`
 
 	DNS         string            `json:"dns,omitempty" bson:"dns,omitempty"`
+	URI         string            `json:"uri,omitempty" bson:"uri,omitempty"`
+	Match       matchtype.Type    `json:"match" bson:"match" swaggertype:"string" enums:"PREFIX,EXACT,REGEX"`
 	Destination string            `json:"destination,omitempty" bson:"destination,omitempty"`
 	Type        redirecttype.Type `json:"type" bson:"type" swaggertype:"string" enums:"PROXY,REDIRECT,IFRAME"`
+	Tags        []string          `json:"tags,omitempty" bson:"tags,omitempty"`
+
+	// StatusCode of REDIRECT answers, 307 by default
+	StatusCode  int          `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Passthrough *Passthrough `json:"passthrough,omitempty" bson:"passthrough,omitempty"`
+	UTM         *UTM         `json:"utm,omitempty" bson:"utm,omitempty"`
+
+	ActiveFrom *time.Time `json:"activeFrom,omitempty" bson:"activeFrom,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	MaxClicks  int64      `json:"maxClicks,omitempty" bson:"maxClicks,omitempty"`
+	Fallback   *Fallback  `json:"fallback,omitempty" bson:"fallback,omitempty"`
+
+	Headers *HeaderPolicy `json:"headers,omitempty" bson:"headers,omitempty"`
+
+	Upstreams []Upstream `json:"upstreams,omitempty" bson:"upstreams,omitempty"`
+	Balancing *Balancing `json:"balancing,omitempty" bson:"balancing,omitempty"`
+
+	Variants []Variant `json:"variants,omitempty" bson:"variants,omitempty"`
+
+	Rules []Rule `json:"rules,omitempty" bson:"rules,omitempty"`
+}
+
+func (redirect Redirect) IsActive(now time.Time) bool {
+	return redirect.ActiveFrom == nil || !now.Before(*redirect.ActiveFrom)
+}
+
+func (redirect Redirect) IsExpired(now time.Time) bool {
+	return redirect.ExpiresAt != nil && !now.Before(*redirect.ExpiresAt)
+}
+
+// NextTransition returns when the redirect becomes active or expires, whichever comes
+// next, or nil when its state won't change anymore.
+func (redirect Redirect) NextTransition(now time.Time) *time.Time {
+	if redirect.ActiveFrom != nil && now.Before(*redirect.ActiveFrom) {
+		return redirect.ActiveFrom
+	}
+
+	if redirect.ExpiresAt != nil && now.Before(*redirect.ExpiresAt) {
+		return redirect.ExpiresAt
+	}
+
+	return nil
 }
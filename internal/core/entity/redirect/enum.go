@@ -28,6 +28,11 @@ var typeValues = map[string]Type{
 	"IFRAME":   IFRAME,
 }
 
+func Parse(name string) (Type, bool) {
+	val, ok := typeValues[name]
+	return val, ok
+}
+
 func (t Type) String() string {
 	if name, ok := typeNames[t]; ok {
 		return name
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	actiontype "fernandoglatz/url-management/internal/core/entity/action"
+)
+
+// Revision is an immutable record of a change to a redirect, holding the whole document
+// before and after it. Revisions are numbered from 1 for each redirect.
+type Revision struct {
+	RedirectID string          `json:"redirectId" bson:"redirectId"`
+	Revision   int64           `json:"revision" bson:"revision"`
+	Action     actiontype.Type `json:"action" bson:"action" swaggertype:"string" enums:"CREATE,UPDATE,DELETE,RESTORE,PURGE"`
+	CreatedAt  time.Time       `json:"createdAt" bson:"createdAt"`
+	Principal  string          `json:"principal,omitempty" bson:"principal,omitempty"`
+	IP         string          `json:"ip,omitempty" bson:"ip,omitempty"`
+	// RestoredRevision is the revision a rollback restored
+	RestoredRevision int64     `json:"restoredRevision,omitempty" bson:"restoredRevision,omitempty"`
+	Before           *Redirect `json:"before,omitempty" bson:"before,omitempty"`
+	After            *Redirect `json:"after,omitempty" bson:"after,omitempty"`
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	devicetype "fernandoglatz/url-management/internal/core/entity/device"
+	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
+)
+
+// Rule sends the visitors matching all of its conditions to its own destination. The
+// rules of a redirect are evaluated in order and the first match wins.
+type Rule struct {
+	Name        string         `json:"name,omitempty" bson:"name,omitempty"`
+	When        RuleConditions `json:"when" bson:"when"`
+	Destination string         `json:"destination" bson:"destination"`
+	// Type of the redirect to the rule destination, defaults to the type of the redirect
+	Type *redirecttype.Type `json:"type,omitempty" bson:"type,omitempty" swaggertype:"string" enums:"PROXY,REDIRECT,IFRAME"`
+}
+
+// RuleConditions are the conditions of a rule. Every condition set must match, and a
+// list condition matches when any of its values does.
+type RuleConditions struct {
+	Devices []devicetype.Type `json:"devices,omitempty" bson:"devices,omitempty" swaggertype:"array,string" enums:"IOS,ANDROID,MOBILE,TABLET,DESKTOP,BOT"`
+	// Languages matched against the preferred language of Accept-Language, "pt" also matching "pt-BR"
+	Languages []string `json:"languages,omitempty" bson:"languages,omitempty"`
+	// Countries as ISO 3166-1 alpha-2 codes, resolved from the GeoIP database
+	Countries []string `json:"countries,omitempty" bson:"countries,omitempty"`
+	// Query parameters and their values, an empty value only requiring the parameter
+	Query map[string]string `json:"query,omitempty" bson:"query,omitempty"`
+	// Headers and their values, an empty value only requiring the header
+	Headers map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
+	Time    *TimeWindow       `json:"time,omitempty" bson:"time,omitempty"`
+}
+
+// TimeWindow matches a time of day, from "HH:MM" inclusive to "HH:MM" exclusive,
+// wrapping past midnight when To is before From.
+type TimeWindow struct {
+	From string `json:"from" bson:"from"`
+	To   string `json:"to" bson:"to"`
+	// Days of the week (MON to SUN) the window applies to, every day when empty
+	Days []string `json:"days,omitempty" bson:"days,omitempty"`
+	// Timezone of the window, defaults to the configured one
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+}
+
+// WithRule returns the redirect as resolved by one of its rules, sending the visitor
+// to the single destination of the rule.
+func (redirect Redirect) WithRule(rule Rule) Redirect {
+	redirect.Destination = rule.Destination
+	if rule.Type != nil {
+		redirect.Type = *rule.Type
+	}
+
+	redirect.Upstreams = nil
+	redirect.Variants = nil
+	redirect.Rules = nil
+
+	return redirect
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// Secret is a key generated by the application when none is configured, stored so
+// that every replica and restart keeps using the same one.
+type Secret struct {
+	Name      string    `bson:"name"`
+	Value     []byte    `bson:"value"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+// Variant is one of the destinations an A/B split redirect sends its visitors to,
+// Weight being its share of the traffic in percent.
+type Variant struct {
+	Name        string `json:"name" bson:"name"`
+	Destination string `json:"destination" bson:"destination"`
+	Weight      int    `json:"weight" bson:"weight"`
+}
+
+type VariantCounter struct {
+	RedirectID  string `json:"-" bson:"redirectId"`
+	Variant     string `json:"variant" bson:"variant"`
+	Hits        int64  `json:"hits" bson:"hits"`
+	Conversions int64  `json:"conversions" bson:"conversions"`
+}
+
+type VariantStats struct {
+	VariantCounter
+	Weight         int     `json:"weight"`
+	ConversionRate float64 `json:"conversionRate"`
+}
@@ -0,0 +1,5 @@
+package request
+
+type ApiKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package request
+
+const (
+	UPSERT_BY_ID  = "id"
+	UPSERT_BY_DNS = "dns"
+)
+
+type ImportOptions struct {
+	Format   string `form:"format"`
+	UpsertBy string `form:"upsertBy"`
+	DryRun   bool   `form:"dryRun"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type Pagination struct {
+	Page int `form:"page"`
+	Size int `form:"size"`
+}
@@ -0,0 +1,17 @@
+package request
+
+import "time"
+
+type RedirectFilter struct {
+	Pagination
+	Sort string `form:"sort"`
+
+	Type            string    `form:"type"`
+	DNS             string    `form:"dns"`
+	DestinationHost string    `form:"destinationHost"`
+	Tags            []string  `form:"tags"`
+	CreatedFrom     time.Time `form:"createdFrom" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo       time.Time `form:"createdTo" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedFrom     time.Time `form:"updatedFrom" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedTo       time.Time `form:"updatedTo" time_format:"2006-01-02T15:04:05Z07:00"`
+}
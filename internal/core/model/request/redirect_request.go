@@ -1,11 +1,36 @@
 package request
 
 import (
+	"fernandoglatz/url-management/internal/core/entity"
+	matchtype "fernandoglatz/url-management/internal/core/entity/match"
 	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
+	"time"
 )
 
 type RedirectRequest struct {
+	Slug        string            `json:"slug,omitempty"`
 	DNS         string            `json:"dns,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Match       matchtype.Type    `json:"match" swaggertype:"string" enums:"PREFIX,EXACT,REGEX"`
 	Destination string            `json:"destination,omitempty"`
 	Type        redirecttype.Type `json:"type" swaggertype:"string" enums:"PROXY,REDIRECT,IFRAME"`
+	Tags        []string          `json:"tags,omitempty"`
+
+	StatusCode  int                 `json:"statusCode"`
+	Passthrough *entity.Passthrough `json:"passthrough"`
+	UTM         *entity.UTM         `json:"utm"`
+
+	ActiveFrom *time.Time       `json:"activeFrom"`
+	ExpiresAt  *time.Time       `json:"expiresAt"`
+	MaxClicks  int64            `json:"maxClicks"`
+	Fallback   *entity.Fallback `json:"fallback"`
+
+	Headers *entity.HeaderPolicy `json:"headers"`
+
+	Upstreams []entity.Upstream `json:"upstreams"`
+	Balancing *entity.Balancing `json:"balancing"`
+
+	Variants []entity.Variant `json:"variants"`
+
+	Rules []entity.Rule `json:"rules"`
 }
@@ -0,0 +1,15 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Visitor describes the request being redirected, as seen by the redirect rules.
+type Visitor struct {
+	IP     string
+	Header http.Header
+	Query  url.Values
+	Time   time.Time
+}
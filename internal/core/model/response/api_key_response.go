@@ -0,0 +1,8 @@
+package response
+
+import "fernandoglatz/url-management/internal/core/entity"
+
+type ApiKeyResponse struct {
+	entity.ApiKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,19 @@
+package response
+
+const (
+	HEALTH_STATUS_UP       = "UP"
+	HEALTH_STATUS_DOWN     = "DOWN"
+	HEALTH_STATUS_DEGRADED = "DEGRADED"
+)
+
+type HealthResponse struct {
+	Status       string                        `json:"status" enums:"UP,DOWN,DEGRADED"`
+	Dependencies map[string]DependencyResponse `json:"dependencies,omitempty"`
+}
+
+type DependencyResponse struct {
+	Status    string  `json:"status" enums:"UP,DOWN"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latencyMs"`
+	Message   string  `json:"message,omitempty"`
+}
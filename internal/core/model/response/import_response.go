@@ -0,0 +1,28 @@
+package response
+
+import "fernandoglatz/url-management/internal/core/common/utils/exceptions"
+
+const (
+	IMPORT_ACTION_CREATE   = "CREATE"
+	IMPORT_ACTION_UPDATE   = "UPDATE"
+	IMPORT_ACTION_CONFLICT = "CONFLICT"
+)
+
+type ImportResponse struct {
+	DryRun    bool                 `json:"dryRun"`
+	Applied   bool                 `json:"applied"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Conflicts int                  `json:"conflicts"`
+	Items     []ImportItemResponse `json:"items"`
+}
+
+type ImportItemResponse struct {
+	Index   int                     `json:"index"`
+	ID      string                  `json:"id,omitempty"`
+	DNS     string                  `json:"dns,omitempty"`
+	URI     string                  `json:"uri,omitempty"`
+	Action  string                  `json:"action"`
+	Message string                  `json:"message,omitempty"`
+	Details []exceptions.FieldError `json:"details,omitempty"`
+}
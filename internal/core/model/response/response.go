@@ -1,6 +1,9 @@
 package response
 
+import "fernandoglatz/url-management/internal/core/common/utils/exceptions"
+
 type Response struct {
 	Code    string
 	Message string
+	Details []exceptions.FieldError `json:",omitempty"`
 }
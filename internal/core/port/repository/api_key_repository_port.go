@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type IApiKeyRepository interface {
+	Get(ctx context.Context, id string) (entity.ApiKey, *exceptions.WrappedError)
+	GetByHash(ctx context.Context, hash string) (entity.ApiKey, *exceptions.WrappedError)
+	GetAll(ctx context.Context) ([]entity.ApiKey, *exceptions.WrappedError)
+	Save(ctx context.Context, apiKey *entity.ApiKey) *exceptions.WrappedError
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type ICertificateRepository interface {
+	Get(ctx context.Context, key string) (entity.Certificate, *exceptions.WrappedError)
+	Save(ctx context.Context, certificate *entity.Certificate) *exceptions.WrappedError
+	Remove(ctx context.Context, key string) *exceptions.WrappedError
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"time"
+)
+
+type IClickRepository interface {
+	SaveAll(ctx context.Context, clicks []entity.Click) *exceptions.WrappedError
+	GetDailyCounters(ctx context.Context, redirectID string, from time.Time, to time.Time) ([]entity.ClickCounter, *exceptions.WrappedError)
+	GetTopReferers(ctx context.Context, redirectID string, from time.Time, to time.Time, limit int) ([]entity.RefererCounter, *exceptions.WrappedError)
+}
@@ -4,12 +4,24 @@ import (
 	"context"
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
 	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"time"
 )
 
 type IRedirectRepository interface {
 	Get(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError)
-	GetByDNS(ctx context.Context, dns string) (entity.Redirect, *exceptions.WrappedError)
+	GetAllByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError)
 	GetAll(ctx context.Context) ([]entity.Redirect, *exceptions.WrappedError)
+	Find(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError)
+	GetExpired(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError)
+	IncrementClicks(ctx context.Context, id string) (int64, *exceptions.WrappedError)
 	Save(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError
+	SaveMany(ctx context.Context, redirects []*entity.Redirect) *exceptions.WrappedError
 	Remove(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError
+	GetTrashed(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError)
+	GetTrashedByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError)
+	FindTrash(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError)
+	GetPurgeable(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError)
+	Restore(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError
+	Purge(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError
 }
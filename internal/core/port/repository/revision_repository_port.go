@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type IRevisionRepository interface {
+	Get(ctx context.Context, redirectID string, revision int64) (entity.Revision, *exceptions.WrappedError)
+	Find(ctx context.Context, redirectID string, page int, size int) ([]entity.Revision, int64, *exceptions.WrappedError)
+	Insert(ctx context.Context, revision *entity.Revision) *exceptions.WrappedError
+}
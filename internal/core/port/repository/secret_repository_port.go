@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+)
+
+type ISecretRepository interface {
+	GetOrCreate(ctx context.Context, name string, value []byte) ([]byte, *exceptions.WrappedError)
+}
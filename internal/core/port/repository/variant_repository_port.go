@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type IVariantRepository interface {
+	IncrementHits(ctx context.Context, redirectID string, variant string) *exceptions.WrappedError
+	IncrementConversions(ctx context.Context, redirectID string, variant string) *exceptions.WrappedError
+	GetAll(ctx context.Context, redirectID string) ([]entity.VariantCounter, *exceptions.WrappedError)
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"time"
+)
+
+type IAnalyticsService interface {
+	Record(ctx context.Context, click entity.Click)
+	GetStats(ctx context.Context, redirectID string, from time.Time, to time.Time) (entity.ClickStats, *exceptions.WrappedError)
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type IApiKeyService interface {
+	Get(ctx context.Context, id string) (entity.ApiKey, *exceptions.WrappedError)
+	GetAll(ctx context.Context) ([]entity.ApiKey, *exceptions.WrappedError)
+	Create(ctx context.Context, name string) (entity.ApiKey, string, *exceptions.WrappedError)
+	Revoke(ctx context.Context, apiKey *entity.ApiKey) *exceptions.WrappedError
+	Authenticate(ctx context.Context, key string) (entity.ApiKey, *exceptions.WrappedError)
+}
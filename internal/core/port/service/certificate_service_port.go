@@ -0,0 +1,12 @@
+package service
+
+import "context"
+
+// ICertificateService is the certificate cache of the ACME client (autocert.Cache) and
+// decides which hosts may get a certificate.
+type ICertificateService interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	HostPolicy(ctx context.Context, host string) error
+}
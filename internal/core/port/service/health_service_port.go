@@ -0,0 +1,11 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/model/response"
+)
+
+type IHealthService interface {
+	Live(ctx context.Context) response.HealthResponse
+	Ready(ctx context.Context) response.HealthResponse
+}
@@ -0,0 +1,11 @@
+package service
+
+import (
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type ILoadBalancerService interface {
+	// Select picks the upstream serving a request. release must be called once the
+	// request is over, reporting whether the upstream answered properly.
+	Select(redirect entity.Redirect, key string) (upstream string, release func(success bool))
+}
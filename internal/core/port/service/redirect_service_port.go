@@ -4,12 +4,24 @@ import (
 	"context"
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
 	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"fernandoglatz/url-management/internal/core/model/response"
 )
 
 type IRedirectService interface {
 	Get(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError)
-	GetByDNS(ctx context.Context, dns string) (entity.Redirect, *exceptions.WrappedError)
+	GetByDNS(ctx context.Context, dns string, path string) (entity.Redirect, *exceptions.WrappedError)
 	GetAll(ctx context.Context) ([]entity.Redirect, *exceptions.WrappedError)
+	Find(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError)
+	ResolveRule(redirect entity.Redirect, visitor request.Visitor) entity.Redirect
+	RegisterClick(ctx context.Context, redirect entity.Redirect) (int64, *exceptions.WrappedError)
 	Save(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError
+	Import(ctx context.Context, redirects []entity.Redirect, options request.ImportOptions) (response.ImportResponse, *exceptions.WrappedError)
+	Export(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, *exceptions.WrappedError)
+	GetHistory(ctx context.Context, id string, pagination request.Pagination) ([]entity.Revision, int64, *exceptions.WrappedError)
+	Rollback(ctx context.Context, id string, revision int64) (entity.Redirect, *exceptions.WrappedError)
 	Remove(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError
+	FindTrash(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError)
+	Restore(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError)
+	Purge(ctx context.Context, id string) *exceptions.WrappedError
 }
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+)
+
+type ISplitService interface {
+	Assign(redirect entity.Redirect, assignment string) (entity.Variant, string)
+	RecordHit(ctx context.Context, redirect entity.Redirect, variant entity.Variant) *exceptions.WrappedError
+	RecordConversion(ctx context.Context, redirect entity.Redirect, assignment string) *exceptions.WrappedError
+	GetStats(ctx context.Context, redirect entity.Redirect) ([]entity.VariantStats, *exceptions.WrappedError)
+}
@@ -2,15 +2,27 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fernandoglatz/url-management/internal/controller"
 	"fernandoglatz/url-management/internal/core/common/router"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/service"
 	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/repository"
 	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const DEFAULT_SHUTDOWN_TIMEOUT = 30 * time.Second
+
+// Setup serves the application until the context is done, then shuts down gracefully:
+// the listeners stop accepting connections, in-flight requests and WebSocket tunnels
+// are drained up to the shutdown timeout, and the background services are stopped.
 func Setup(ctx context.Context) error {
 	log.Info(ctx).Msg("Starting web server")
 
@@ -36,9 +48,107 @@ func Setup(ctx context.Context) error {
 		controller.LoggingMiddleware(),
 		controller.RecoveryMiddleware(ctx),
 	)
+	if config.ApplicationConfig.Metrics.Enabled {
+		engine.Use(controller.MetricsMiddleware())
+	}
+
+	// the background services outlive the signal, so they still serve the requests
+	// being drained
+	servicesCtx, stopServices := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopServices()
+
+	drain := router.Setup(servicesCtx, engine)
+
+	servers := []*http.Server{newServer(listening, engine)}
+	if serverConfig.TLS.Enabled {
+		redirectRepository := repository.NewRedirectCacheRepository(repository.NewRedirectRepository())
+		certificateService := service.NewCertificateService(repository.NewCertificateRepository(), redirectRepository)
 
-	router.Setup(ctx, engine)
+		tlsServers, err := newTLSServers(engine, certificateService)
+		if err != nil {
+			return err
+		}
+		servers = tlsServers
+	}
+
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			errs <- listen(server)
+		}()
+	}
 
 	log.Info(ctx).Msg("Web server listening on " + listening + contextPath)
-	return engine.Run(listening)
+	if serverConfig.TLS.Enabled {
+		log.Info(ctx).Msg("Web server listening with TLS on " + serverConfig.TLS.Listening + contextPath)
+	}
+
+	var err error
+	select {
+	case err = <-errs:
+		log.Error(ctx).Msg("Web server failed: " + err.Error())
+	case <-ctx.Done():
+		log.Info(ctx).Msg("Shutting down web server")
+	}
+
+	shutdownTimeout := serverConfig.ShutdownTimeout
+	if shutdownTimeout <= constants.ZERO {
+		shutdownTimeout = DEFAULT_SHUTDOWN_TIMEOUT
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	shutdown(shutdownCtx, servers)
+	drain(shutdownCtx)
+
+	log.Info(ctx).Msg("Web server stopped")
+	return err
+}
+
+func newServer(listening string, handler http.Handler) *http.Server {
+	serverConfig := config.ApplicationConfig.Server
+
+	return &http.Server{
+		Addr:              listening,
+		Handler:           handler,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+	}
+}
+
+func listen(server *http.Server) error {
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// shutdown stops every listener at once and waits for their in-flight requests,
+// closing the connections still active when the context is done.
+func shutdown(ctx context.Context, servers []*http.Server) {
+	var wg sync.WaitGroup
+
+	for _, server := range servers {
+		wg.Add(constants.ONE)
+		go func() {
+			defer wg.Done()
+
+			if err := server.Shutdown(ctx); err != nil {
+				log.Warn(ctx).Msg("Web server on " + server.Addr + " not drained: " + err.Error())
+				server.Close()
+			}
+		}()
+	}
+
+	wg.Wait()
 }
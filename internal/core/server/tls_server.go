@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/port/service"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"net"
+	"net/http"
+	"os"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const DEFAULT_HTTPS_PORT = "443"
+
+// newTLSServers serves the handler over HTTPS with certificates obtained from ACME,
+// next to the plain HTTP listener, which answers the HTTP-01 challenges and redirects
+// the hosts that can have a certificate to HTTPS.
+func newTLSServers(handler http.Handler, certificateService service.ICertificateService) ([]*http.Server, error) {
+	serverConfig := config.ApplicationConfig.Server
+	tlsConfig := serverConfig.TLS
+
+	manager, err := newCertificateManager(certificateService)
+	if err != nil {
+		return nil, err
+	}
+
+	httpHandler := handler
+	if tlsConfig.RedirectHTTP {
+		httpHandler = redirectToHTTPS(handler, manager.HostPolicy, publicPort())
+	}
+
+	httpServer := newServer(serverConfig.Listening, manager.HTTPHandler(httpHandler))
+	httpsServer := newServer(tlsConfig.Listening, handler)
+	httpsServer.TLSConfig = manager.TLSConfig()
+
+	return []*http.Server{httpServer, httpsServer}, nil
+}
+
+func newCertificateManager(certificateService service.ICertificateService) (*autocert.Manager, error) {
+	acmeConfig := config.ApplicationConfig.Server.TLS.ACME
+
+	client := &acme.Client{
+		DirectoryURL: acmeConfig.DirectoryURL,
+	}
+
+	// a private CA, such as a local Pebble test server, signs its ACME endpoint with
+	// a certificate of its own
+	if utils.IsNotEmptyStr(acmeConfig.CAFile) {
+		pem, err := os.ReadFile(acmeConfig.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificate found in ACME CA file " + acmeConfig.CAFile)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+		client.HTTPClient = &http.Client{Transport: transport}
+	}
+
+	return &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       certificateService,
+		HostPolicy:  certificateService.HostPolicy,
+		Client:      client,
+		Email:       acmeConfig.Email,
+		RenewBefore: acmeConfig.RenewBefore,
+	}, nil
+}
+
+// publicPort is the port clients reach the HTTPS listener on, which differs from the
+// listening one behind a port mapping.
+func publicPort() string {
+	tlsConfig := config.ApplicationConfig.Server.TLS
+	if utils.IsNotEmptyStr(tlsConfig.PublicPort) {
+		return tlsConfig.PublicPort
+	}
+
+	_, port, _ := net.SplitHostPort(tlsConfig.Listening)
+	return port
+}
+
+// redirectToHTTPS permanently redirects plain HTTP requests of the hosts allowed a
+// certificate to the HTTPS listener, and serves every other host as is.
+func redirectToHTTPS(handler http.Handler, hostPolicy autocert.HostPolicy, port string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		host := request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+
+		if hostPolicy(request.Context(), host) != nil {
+			handler.ServeHTTP(writer, request)
+			return
+		}
+
+		if utils.IsNotEmptyStr(port) && port != DEFAULT_HTTPS_PORT {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(writer, request, "https://"+host+request.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/entity"
+	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
+	"fernandoglatz/url-management/internal/core/service"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/repository"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	PEBBLE_DIRECTORY_URL_ENV = "PEBBLE_DIRECTORY_URL"
+	MONGO_TEST_URI_ENV       = "MONGO_TEST_URI"
+
+	DEFAULT_PEBBLE_DIRECTORY_URL = "https://localhost:14000/dir"
+	DEFAULT_MONGO_TEST_URI       = "mongodb://localhost:27017/?directConnection=true&serverSelectionTimeoutMS=2000"
+	TEST_DATABASE                = "url-management-test"
+
+	// PEBBLE_CA_FILE signs the ACME endpoint of Pebble 2.8.0, the version of the
+	// compose file, see https://github.com/letsencrypt/pebble/tree/v2.8.0/test/certs
+	PEBBLE_CA_FILE = "testdata/pebble.minica.pem"
+
+	REGISTERED_HOST = "registered.example.com"
+	WILDCARD_HOST   = "shop.wildcard.example.com"
+	EXPIRED_HOST    = "expired.example.com"
+	INACTIVE_HOST   = "inactive.example.com"
+	UNKNOWN_HOST    = "unknown.example.com"
+)
+
+// TestACMEWithPebble issues certificates from the Pebble and MongoDB of
+// `docker compose --profile acme up`, whose Pebble accepts every challenge. It is
+// skipped when either of them can't be reached.
+func TestACMEWithPebble(t *testing.T) {
+	ctx := context.Background()
+
+	directoryURL := os.Getenv(PEBBLE_DIRECTORY_URL_ENV)
+	if directoryURL == "" {
+		directoryURL = DEFAULT_PEBBLE_DIRECTORY_URL
+	}
+	caFile, err := filepath.Abs(PEBBLE_CA_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipUnlessPebble(t, directoryURL, caFile)
+	connectTestMongo(t)
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	redirectRepository := repository.NewRedirectRepository()
+	for _, redirect := range []*entity.Redirect{
+		{DNS: REGISTERED_HOST},
+		{DNS: "*.wildcard.example.com"},
+		{DNS: "*"},
+		{DNS: EXPIRED_HOST, ExpiresAt: &past},
+		{DNS: INACTIVE_HOST, ActiveFrom: &future},
+	} {
+		redirect.Type = redirecttype.REDIRECT
+		redirect.Destination = "https://destination.example.org"
+		if errw := redirectRepository.Save(ctx, redirect); errw != nil {
+			t.Fatalf("saving redirect of %s: %s", redirect.DNS, errw.GetMessage())
+		}
+	}
+
+	certificateService := service.NewCertificateService(repository.NewCertificateRepository(), redirectRepository)
+
+	config.ApplicationConfig.Server.Listening = "127.0.0.1:0"
+	config.ApplicationConfig.Server.TLS.Enabled = true
+	config.ApplicationConfig.Server.TLS.Listening = "127.0.0.1:0"
+	config.ApplicationConfig.Server.TLS.RedirectHTTP = true
+	config.ApplicationConfig.Server.TLS.PublicPort = "8443"
+	config.ApplicationConfig.Server.TLS.ACME.DirectoryURL = directoryURL
+	config.ApplicationConfig.Server.TLS.ACME.CAFile = caFile
+
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+	servers, err := newTLSServers(handler, certificateService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer, httpsServer := servers[0], servers[1]
+
+	t.Run("cache round trip", func(t *testing.T) {
+		key := "round-trip.example.com"
+		if _, err := certificateService.Get(ctx, key); !errors.Is(err, autocert.ErrCacheMiss) {
+			t.Fatalf("expected a cache miss, got %v", err)
+		}
+
+		if err := certificateService.Put(ctx, key, []byte("first")); err != nil {
+			t.Fatal(err)
+		}
+		if err := certificateService.Put(ctx, key, []byte("second")); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := certificateService.Get(ctx, key)
+		if err != nil || string(data) != "second" {
+			t.Fatalf("expected the last value put, got %q, %v", data, err)
+		}
+
+		if err := certificateService.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := certificateService.Get(ctx, key); !errors.Is(err, autocert.ErrCacheMiss) {
+			t.Fatalf("expected a cache miss after delete, got %v", err)
+		}
+	})
+
+	t.Run("host policy", func(t *testing.T) {
+		tests := []struct {
+			host    string
+			allowed bool
+		}{
+			{REGISTERED_HOST, true},
+			{"Registered.Example.COM", true},
+			{WILDCARD_HOST, true},
+			{EXPIRED_HOST, false},
+			{INACTIVE_HOST, false},
+			{UNKNOWN_HOST, false},
+		}
+
+		for _, test := range tests {
+			err := certificateService.HostPolicy(ctx, test.host)
+			if allowed := err == nil; allowed != test.allowed {
+				t.Errorf("%s: expected allowed=%v, got %v", test.host, test.allowed, err)
+			}
+		}
+	})
+
+	t.Run("issues a certificate for a registered host", func(t *testing.T) {
+		for _, host := range []string{REGISTERED_HOST, WILDCARD_HOST} {
+			certificate, err := httpsServer.TLSConfig.GetCertificate(clientHello(host))
+			if err != nil {
+				t.Fatalf("%s: %v", host, err)
+			}
+
+			leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Contains(leaf.DNSNames, host) {
+				t.Errorf("%s: certificate issued for %v", host, leaf.DNSNames)
+			}
+
+			if _, err := certificateService.Get(ctx, host); err != nil {
+				t.Errorf("%s: certificate not cached in MongoDB: %v", host, err)
+			}
+		}
+	})
+
+	t.Run("refuses a certificate for an unregistered host", func(t *testing.T) {
+		for _, host := range []string{UNKNOWN_HOST, EXPIRED_HOST, INACTIVE_HOST} {
+			if _, err := httpsServer.TLSConfig.GetCertificate(clientHello(host)); err == nil {
+				t.Errorf("%s: expected no certificate", host)
+			}
+		}
+	})
+
+	t.Run("redirects the registered hosts to HTTPS", func(t *testing.T) {
+		tests := []struct {
+			host     string
+			status   int
+			location string
+		}{
+			{REGISTERED_HOST, http.StatusPermanentRedirect, "https://" + REGISTERED_HOST + ":8443/path?query=1"},
+			{WILDCARD_HOST + ":8080", http.StatusPermanentRedirect, "https://" + WILDCARD_HOST + ":8443/path?query=1"},
+			{UNKNOWN_HOST, http.StatusOK, ""},
+			{EXPIRED_HOST, http.StatusOK, ""},
+		}
+
+		for _, test := range tests {
+			request := httptest.NewRequest(http.MethodGet, "http://"+test.host+"/path?query=1", nil)
+			recorder := httptest.NewRecorder()
+			httpServer.Handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status || recorder.Header().Get("Location") != test.location {
+				t.Errorf("%s: expected %d %q, got %d %q", test.host, test.status, test.location, recorder.Code, recorder.Header().Get("Location"))
+			}
+		}
+	})
+}
+
+func skipUnlessPebble(t *testing.T, directoryURL string, caFile string) {
+	t.Helper()
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(pem)
+
+	client := &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}},
+	}
+	response, err := client.Get(directoryURL)
+	var verificationErr *tls.CertificateVerificationError
+	if errors.As(err, &verificationErr) {
+		t.Fatal("Pebble isn't signed by " + PEBBLE_CA_FILE + ": " + err.Error())
+	}
+	if err != nil {
+		t.Skip("Pebble unreachable: " + err.Error())
+	}
+	response.Body.Close()
+}
+
+// connectTestMongo connects to a scratch database, migrated as the application does,
+// which is dropped once the test ends.
+func connectTestMongo(t *testing.T) {
+	t.Helper()
+
+	uri := os.Getenv(MONGO_TEST_URI_ENV)
+	if uri == "" {
+		uri = DEFAULT_MONGO_TEST_URI
+	}
+	config.ApplicationConfig.Data.Mongo.Uri = uri
+	config.ApplicationConfig.Data.Mongo.Database = TEST_DATABASE
+
+	// the migrations are read relative to the root of the repository
+	t.Chdir("../../..")
+
+	ctx := context.Background()
+	if err := utils.ConnectToMongoDB(ctx); err != nil {
+		t.Skip("MongoDB unreachable: " + err.Error())
+	}
+
+	t.Cleanup(func() {
+		utils.MongoDatabase.Client.Drop(ctx)
+		utils.DisconnectFromMongoDB(ctx)
+	})
+}
+
+func clientHello(host string) *tls.ClientHelloInfo {
+	return &tls.ClientHelloInfo{
+		ServerName:        host,
+		CipherSuites:      []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		SupportedCurves:   []tls.CurveID{tls.CurveP256},
+		SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedVersions: []uint16{tls.VersionTLS13, tls.VersionTLS12},
+	}
+}
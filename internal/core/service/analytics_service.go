@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/port/repository"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_FLUSH_INTERVAL = 5 * time.Second
+	IP_SECRET_NAME         = "analytics-ip"
+	IP_SECRET_SIZE         = 32
+)
+
+// AnalyticsService records redirect hits without touching the request path: Record
+// only enqueues the click, and a background worker enriches and writes batches.
+// The clicks channel is never closed, as requests cut by a shutdown deadline may
+// still be recording: Stop signals the worker through stopping instead.
+type AnalyticsService struct {
+	repository repository.IClickRepository
+	ipSecret   []byte
+	clicks     chan entity.Click
+	stopping   chan struct{}
+	done       chan struct{}
+	mutex      sync.RWMutex
+	stopped    bool
+}
+
+func NewAnalyticsService(repository repository.IClickRepository, secretRepository repository.ISecretRepository) *AnalyticsService {
+	analyticsConfig := config.ApplicationConfig.Analytics
+
+	return &AnalyticsService{
+		repository: repository,
+		ipSecret:   loadIPSecret(secretRepository),
+		clicks:     make(chan entity.Click, analyticsConfig.BufferSize),
+		stopping:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+func (service *AnalyticsService) Start(ctx context.Context) {
+	go service.run(ctx)
+}
+
+// Stop flushes the buffered clicks, waiting at most until the context is done. The
+// clicks recorded afterwards are dropped.
+func (service *AnalyticsService) Stop(ctx context.Context) {
+	service.mutex.Lock()
+	if !service.stopped {
+		service.stopped = true
+		close(service.stopping)
+	}
+	service.mutex.Unlock()
+
+	select {
+	case <-service.done:
+	case <-ctx.Done():
+		log.Warn(ctx).Msg("Analytics buffer not fully flushed: " + ctx.Err().Error())
+	}
+}
+
+func (service *AnalyticsService) Record(ctx context.Context, click entity.Click) {
+	if !config.ApplicationConfig.Analytics.Enabled {
+		return
+	}
+
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	if service.stopped {
+		log.Warn(ctx).Msg("Analytics stopped, dropping click for redirect " + click.RedirectID)
+		return
+	}
+
+	select {
+	case service.clicks <- click:
+	default:
+		log.Warn(ctx).Msg("Analytics buffer full, dropping click for redirect " + click.RedirectID)
+	}
+}
+
+func (service *AnalyticsService) run(ctx context.Context) {
+	defer close(service.done)
+
+	analyticsConfig := config.ApplicationConfig.Analytics
+	batchSize := analyticsConfig.BatchSize
+	if batchSize <= constants.ZERO {
+		batchSize = constants.ONE
+	}
+
+	flushInterval := analyticsConfig.FlushInterval
+	if flushInterval <= constants.ZERO {
+		flushInterval = DEFAULT_FLUSH_INTERVAL
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]entity.Click, 0, batchSize)
+	for {
+		select {
+		case <-service.stopping:
+			service.drain(ctx, batch, batchSize)
+			return
+
+		case click := <-service.clicks:
+			batch = append(batch, click)
+			if len(batch) >= batchSize {
+				service.flush(ctx, batch)
+				batch = make([]entity.Click, 0, batchSize)
+			}
+
+		case <-ticker.C:
+			if len(batch) > constants.ZERO {
+				service.flush(ctx, batch)
+				batch = make([]entity.Click, 0, batchSize)
+			}
+		}
+	}
+}
+
+// drain flushes the batch and the clicks left in the buffer. Once stopping is closed no
+// click is enqueued anymore, so the buffer can only shrink.
+func (service *AnalyticsService) drain(ctx context.Context, batch []entity.Click, batchSize int) {
+	for {
+		select {
+		case click := <-service.clicks:
+			batch = append(batch, click)
+			if len(batch) >= batchSize {
+				service.flush(ctx, batch)
+				batch = make([]entity.Click, 0, batchSize)
+			}
+
+		default:
+			service.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+func (service *AnalyticsService) flush(ctx context.Context, clicks []entity.Click) {
+	if len(clicks) == constants.ZERO {
+		return
+	}
+
+	for index := range clicks {
+		click := &clicks[index]
+		if utils.IsNotEmptyStr(click.IP) {
+			click.Country = utils.GeoIPDatabase.Country(click.IP)
+			click.IPHash = service.hashIP(click.IP)
+		}
+	}
+
+	errw := service.repository.SaveAll(ctx, clicks)
+	if errw != nil {
+		log.Error(ctx).Msg("Error saving " + strconv.Itoa(len(clicks)) + " clicks: " + errw.GetMessage())
+	}
+}
+
+func (service *AnalyticsService) GetStats(ctx context.Context, redirectID string, from time.Time, to time.Time) (entity.ClickStats, *exceptions.WrappedError) {
+	stats := entity.ClickStats{}
+
+	from = utils.StartOfDay(from)
+	to = utils.StartOfDay(to)
+
+	counters, errw := service.repository.GetDailyCounters(ctx, redirectID, from, to)
+	if errw != nil {
+		return stats, errw
+	}
+
+	limit := config.ApplicationConfig.Analytics.TopReferers
+	if limit <= constants.ZERO {
+		limit = constants.TEN
+	}
+
+	referers, errw := service.repository.GetTopReferers(ctx, redirectID, from, to.AddDate(0, 0, 1), limit)
+	if errw != nil {
+		return stats, errw
+	}
+
+	var total int64
+	countByDay := make(map[int64]int64)
+	for _, counter := range counters {
+		countByDay[counter.Day.Unix()] = counter.Count
+		total += counter.Count
+	}
+
+	series := []entity.ClickCounter{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		series = append(series, entity.ClickCounter{
+			RedirectID: redirectID,
+			Day:        day,
+			Count:      countByDay[day.Unix()],
+		})
+	}
+
+	stats.Total = total
+	stats.Series = series
+	stats.TopReferers = referers
+
+	return stats, nil
+}
+
+// hashIP keys the hash of an IP with a secret, as a plain hash of one of the 2^32 IPv4
+// addresses is trivially reversed.
+func (service *AnalyticsService) hashIP(ip string) string {
+	mac := hmac.New(sha256.New, service.ipSecret)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loadIPSecret returns the configured IP hashing secret or else a random one, stored in
+// Mongo so every replica and restart hashes the same IP the same way. When it can't be
+// stored, the random secret is only used until the next restart.
+func loadIPSecret(secretRepository repository.ISecretRepository) []byte {
+	analyticsConfig := config.ApplicationConfig.Analytics
+	if utils.IsNotEmptyStr(analyticsConfig.IPSecret) {
+		return []byte(analyticsConfig.IPSecret)
+	}
+
+	secret := make([]byte, IP_SECRET_SIZE)
+	if _, err := cryptorand.Read(secret); err != nil {
+		panic(err)
+	}
+
+	if !analyticsConfig.Enabled {
+		return secret
+	}
+
+	ctx := context.Background()
+	stored, errw := secretRepository.GetOrCreate(ctx, IP_SECRET_NAME, secret)
+	if errw != nil {
+		log.Error(ctx).Msg("Error loading the IP hashing secret, IPs will be hashed differently after a restart: " + errw.GetMessage())
+		return secret
+	}
+
+	return stored
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/port/repository"
+	"time"
+)
+
+const (
+	API_KEY_PREFIX        = "um_"
+	API_KEY_RANDOM_BYTES  = 24
+	API_KEY_PREFIX_LENGTH = 11
+)
+
+type ApiKeyService struct {
+	repository repository.IApiKeyRepository
+}
+
+func NewApiKeyService(repository repository.IApiKeyRepository) *ApiKeyService {
+	return &ApiKeyService{
+		repository: repository,
+	}
+}
+
+func (service *ApiKeyService) Get(ctx context.Context, id string) (entity.ApiKey, *exceptions.WrappedError) {
+	return service.repository.Get(ctx, id)
+}
+
+func (service *ApiKeyService) GetAll(ctx context.Context) ([]entity.ApiKey, *exceptions.WrappedError) {
+	return service.repository.GetAll(ctx)
+}
+
+// Create issues a new API key. Only its SHA-256 hash is persisted, so the plain
+// key is returned to the caller once and cannot be recovered afterwards.
+func (service *ApiKeyService) Create(ctx context.Context, name string) (entity.ApiKey, string, *exceptions.WrappedError) {
+	randomBytes := make([]byte, API_KEY_RANDOM_BYTES)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return entity.ApiKey{}, "", &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	key := API_KEY_PREFIX + hex.EncodeToString(randomBytes)
+	apiKey := entity.ApiKey{
+		Name:   name,
+		Prefix: key[:API_KEY_PREFIX_LENGTH],
+		Hash:   hashApiKey(key),
+	}
+
+	errw := service.repository.Save(ctx, &apiKey)
+	if errw != nil {
+		return apiKey, "", errw
+	}
+
+	return apiKey, key, nil
+}
+
+func (service *ApiKeyService) Revoke(ctx context.Context, apiKey *entity.ApiKey) *exceptions.WrappedError {
+	if apiKey.IsRevoked() {
+		return nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+
+	return service.repository.Save(ctx, apiKey)
+}
+
+func (service *ApiKeyService) Authenticate(ctx context.Context, key string) (entity.ApiKey, *exceptions.WrappedError) {
+	apiKey, errw := service.repository.GetByHash(ctx, hashApiKey(key))
+	if errw != nil {
+		if errw.BaseError == exceptions.RecordNotFound {
+			return apiKey, &exceptions.WrappedError{
+				BaseError: exceptions.Unauthorized,
+			}
+		}
+		return apiKey, errw
+	}
+
+	if apiKey.IsRevoked() {
+		return apiKey, &exceptions.WrappedError{
+			BaseError: exceptions.Unauthorized,
+			Message:   "API key revoked.",
+		}
+	}
+
+	return apiKey, nil
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/port/repository"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+type CertificateService struct {
+	repository         repository.ICertificateRepository
+	redirectRepository repository.IRedirectRepository
+}
+
+func NewCertificateService(repository repository.ICertificateRepository, redirectRepository repository.IRedirectRepository) *CertificateService {
+	return &CertificateService{
+		repository:         repository,
+		redirectRepository: redirectRepository,
+	}
+}
+
+func (service *CertificateService) Get(ctx context.Context, key string) ([]byte, error) {
+	certificate, errw := service.repository.Get(ctx, key)
+	if errw != nil && errw.BaseError == exceptions.RecordNotFound {
+		return nil, autocert.ErrCacheMiss
+	} else if errw != nil {
+		return nil, errors.New(errw.GetMessage())
+	}
+
+	return certificate.Data, nil
+}
+
+func (service *CertificateService) Put(ctx context.Context, key string, data []byte) error {
+	certificate := &entity.Certificate{
+		Key:  key,
+		Data: data,
+	}
+
+	if errw := service.repository.Save(ctx, certificate); errw != nil {
+		return errors.New(errw.GetMessage())
+	}
+
+	return nil
+}
+
+func (service *CertificateService) Delete(ctx context.Context, key string) error {
+	if errw := service.repository.Remove(ctx, key); errw != nil {
+		return errors.New(errw.GetMessage())
+	}
+
+	return nil
+}
+
+// HostPolicy only allows certificates for the hostnames a redirect serves right now,
+// resolved as GetByDNS does: exact entries and wildcard entries of a parent domain,
+// leaving out the redirects not active yet and the expired ones. The catch-all "*"
+// entry isn't enough, as it would get a certificate for any hostname pointed at the
+// server.
+func (service *CertificateService) HostPolicy(ctx context.Context, host string) error {
+	candidates := utils.DNSCandidates(host)
+	candidates = candidates[:len(candidates)-constants.ONE]
+
+	redirects, errw := service.redirectRepository.GetAllByDNS(ctx, candidates)
+	if errw != nil {
+		return errors.New(errw.GetMessage())
+	}
+
+	now := time.Now()
+	for _, redirect := range redirects {
+		if redirect.IsActive(now) && !redirect.IsExpired(now) {
+			return nil
+		}
+	}
+
+	return errors.New("host " + strings.ToLower(host) + " isn't served by any redirect")
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"strconv"
+	"time"
+)
+
+const (
+	DEFAULT_PURGE_INTERVAL = time.Hour
+	PURGE_BATCH_SIZE       = 100
+	PURGE_PRINCIPAL        = "system:expiration"
+)
+
+// StartExpirationSweeper periodically removes redirects that expired longer than the
+// configured purge-after ago. It does nothing unless purging is enabled.
+func (service *RedirectService) StartExpirationSweeper(ctx context.Context) {
+	expirationConfig := config.ApplicationConfig.Redirect.Expiration
+	if !expirationConfig.Purge {
+		return
+	}
+
+	interval := expirationConfig.PurgeInterval
+	if interval <= constants.ZERO {
+		interval = DEFAULT_PURGE_INTERVAL
+	}
+
+	// purges are recorded in the history of the redirects as made by the sweeper
+	ctx = context.WithValue(ctx, constants.PRINCIPAL, PURGE_PRINCIPAL)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				service.purgeExpired(ctx)
+			}
+		}
+	}()
+}
+
+func (service *RedirectService) purgeExpired(ctx context.Context) {
+	before := time.Now().Add(-config.ApplicationConfig.Redirect.Expiration.PurgeAfter)
+	purged := 0
+
+	for {
+		redirects, errw := service.repository.GetExpired(ctx, before, PURGE_BATCH_SIZE)
+		if errw != nil {
+			log.Error(ctx).Msg("Error searching expired redirects: " + errw.GetMessage())
+			break
+		}
+
+		for _, redirect := range redirects {
+			if errw := service.Remove(ctx, redirect); errw != nil {
+				log.Error(ctx).Msg("Error purging redirect " + redirect.ID + ": " + errw.GetMessage())
+				return
+			}
+			purged++
+		}
+
+		if len(redirects) < PURGE_BATCH_SIZE {
+			break
+		}
+	}
+
+	if purged > constants.ZERO {
+		log.Info(ctx).Msg("Purged " + strconv.Itoa(purged) + " expired redirects")
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/model/response"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DEFAULT_HEALTH_TIMEOUT = 2 * time.Second
+	HEALTH_CACHE_KEY       = "url-management:health:"
+	HEALTH_CACHE_TTL       = 10 * time.Second
+
+	MONGO_DEPENDENCY      = "mongo"
+	REDIS_DEPENDENCY      = "redis"
+	MIGRATIONS_DEPENDENCY = "migrations"
+	CACHE_DEPENDENCY      = "cache"
+)
+
+type healthCheck func(ctx context.Context) error
+
+// HealthService checks the dependencies of the application. Only the required ones
+// make it unready; the others, such as Redis which the repositories can do without,
+// only make it degraded.
+type HealthService struct {
+	checks   map[string]healthCheck
+	required map[string]bool
+	cacheKey string
+}
+
+func NewHealthService() *HealthService {
+	service := &HealthService{
+		required: make(map[string]bool),
+		cacheKey: HEALTH_CACHE_KEY + uuid.NewString(),
+	}
+
+	service.checks = map[string]healthCheck{
+		MONGO_DEPENDENCY:      checkMongo,
+		REDIS_DEPENDENCY:      checkRedis,
+		MIGRATIONS_DEPENDENCY: checkMigrations,
+		CACHE_DEPENDENCY:      service.checkCache,
+	}
+
+	for _, name := range config.ApplicationConfig.Health.Required {
+		if _, found := service.checks[name]; !found {
+			log.Warn(context.Background()).Msg("Unknown required health dependency [" + name + "], ignoring it")
+			continue
+		}
+		service.required[name] = true
+	}
+
+	return service
+}
+
+// Live only tells the process is up and serving, without looking at the dependencies,
+// so an outage of one of them never gets the application restarted.
+func (service *HealthService) Live(ctx context.Context) response.HealthResponse {
+	return response.HealthResponse{
+		Status: response.HEALTH_STATUS_UP,
+	}
+}
+
+// Ready checks every dependency at once, each bounded by the configured timeout.
+func (service *HealthService) Ready(ctx context.Context) response.HealthResponse {
+	timeout := config.ApplicationConfig.Health.Timeout
+	if timeout <= constants.ZERO {
+		timeout = DEFAULT_HEALTH_TIMEOUT
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	health := response.HealthResponse{
+		Status:       response.HEALTH_STATUS_UP,
+		Dependencies: make(map[string]response.DependencyResponse),
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, check := range service.checks {
+		wg.Add(constants.ONE)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+
+			dependency := response.DependencyResponse{
+				Status:    response.HEALTH_STATUS_UP,
+				Required:  service.required[name],
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			if err != nil {
+				dependency.Status = response.HEALTH_STATUS_DOWN
+				dependency.Message = err.Error()
+			}
+
+			mutex.Lock()
+			health.Dependencies[name] = dependency
+			mutex.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	for _, dependency := range health.Dependencies {
+		if dependency.Status == response.HEALTH_STATUS_UP {
+			continue
+		}
+
+		if dependency.Required {
+			health.Status = response.HEALTH_STATUS_DOWN
+			break
+		}
+		health.Status = response.HEALTH_STATUS_DEGRADED
+	}
+
+	return health
+}
+
+func checkMongo(ctx context.Context) error {
+	return utils.MongoDatabase.Ping(ctx)
+}
+
+func checkRedis(ctx context.Context) error {
+	return utils.RedisDatabase.Ping(ctx)
+}
+
+// checkMigrations fails when the migrations are dirty or behind the version this
+// instance brought them to. Being ahead is fine: it happens during a rolling deploy,
+// once a newer instance migrated.
+func checkMigrations(ctx context.Context) error {
+	version, dirty, err := utils.MongoDatabase.GetMigrationState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return errors.New("migration " + strconv.Itoa(version) + " failed halfway")
+	}
+
+	expected := utils.MongoDatabase.MigrationVersion
+	if version < expected {
+		return errors.New("migrations are at version " + strconv.Itoa(version) + ", expected " + strconv.Itoa(expected))
+	}
+
+	return nil
+}
+
+// checkCache writes a value to the cache and reads it back, which a reachable Redis
+// can still fail at, when it is out of memory or a read-only replica.
+func (service *HealthService) checkCache(ctx context.Context) error {
+	value := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	err := utils.RedisDatabase.Set(ctx, service.cacheKey, value, HEALTH_CACHE_TTL)
+	if err != nil {
+		return err
+	}
+
+	cached, err := utils.RedisDatabase.Get(ctx, service.cacheKey).Result()
+	if err != nil {
+		return err
+	}
+
+	if cached != value {
+		return errors.New("cache returned a stale value")
+	}
+
+	return nil
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/entity"
+	balancingtype "fernandoglatz/url-management/internal/core/entity/balancing"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	HEALTH_CHECK_TICK             = time.Second
+	UPSTREAM_POOL_IDLE_TIMEOUT    = 10 * time.Minute
+	DEFAULT_HEALTH_CHECK_INTERVAL = 10 * time.Second
+	DEFAULT_HEALTH_CHECK_TIMEOUT  = 2 * time.Second
+)
+
+type upstreamState struct {
+	active              int
+	currentWeight       int
+	consecutiveFailures int
+	ejectedUntil        time.Time
+
+	// active health checks
+	unhealthy      bool
+	checkFailures  int
+	checkSuccesses int
+}
+
+// upstreamPool holds the balancing state of one redirect, along with the last version
+// of the redirect seen so health checks follow its upstreams as they are edited.
+type upstreamPool struct {
+	mutex     sync.Mutex
+	redirect  entity.Redirect
+	states    map[string]*upstreamState
+	lastUsed  time.Time
+	nextCheck time.Time
+	checking  bool
+}
+
+// LoadBalancerService spreads the traffic of redirects with several upstreams. Upstreams
+// failing consecutive requests are ejected for a while, and redirects with a health
+// check path have their upstreams probed in the background.
+type LoadBalancerService struct {
+	mutex  sync.Mutex
+	pools  map[string]*upstreamPool
+	client *http.Client
+}
+
+func NewLoadBalancerService() *LoadBalancerService {
+	timeout := config.ApplicationConfig.Balancing.HealthCheck.Timeout
+	if timeout <= constants.ZERO {
+		timeout = DEFAULT_HEALTH_CHECK_TIMEOUT
+	}
+
+	return &LoadBalancerService{
+		pools: make(map[string]*upstreamPool),
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(request *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (service *LoadBalancerService) Start(ctx context.Context) {
+	go service.run(ctx)
+}
+
+func (service *LoadBalancerService) Select(redirect entity.Redirect, key string) (string, func(bool)) {
+	upstreams := redirect.GetUpstreams()
+	if len(upstreams) == constants.ONE {
+		return upstreams[0].URL, func(bool) {}
+	}
+
+	now := time.Now()
+	pool := service.pool(redirect.ID)
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.redirect = redirect
+	pool.lastUsed = now
+
+	candidates := make([]entity.Upstream, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		if pool.state(upstream.URL).isAvailable(now) {
+			candidates = append(candidates, upstream)
+		}
+	}
+
+	// with every upstream down, keep trying all of them rather than failing outright
+	if len(candidates) == constants.ZERO {
+		candidates = upstreams
+	}
+
+	strategy := balancingtype.ROUND_ROBIN
+	if redirect.Balancing != nil {
+		strategy = redirect.Balancing.Strategy
+	}
+
+	upstream := pool.choose(strategy, candidates, key)
+	pool.state(upstream.URL).active++
+
+	released := false
+	return upstream.URL, func(success bool) {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+
+		if !released {
+			released = true
+			pool.release(upstream.URL, success)
+		}
+	}
+}
+
+func (service *LoadBalancerService) pool(id string) *upstreamPool {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	pool, ok := service.pools[id]
+	if !ok {
+		pool = &upstreamPool{
+			states: make(map[string]*upstreamState),
+		}
+		service.pools[id] = pool
+	}
+
+	return pool
+}
+
+func (pool *upstreamPool) state(upstreamURL string) *upstreamState {
+	state, ok := pool.states[upstreamURL]
+	if !ok {
+		state = &upstreamState{}
+		pool.states[upstreamURL] = state
+	}
+	return state
+}
+
+func (state *upstreamState) isAvailable(now time.Time) bool {
+	return !state.unhealthy && !now.Before(state.ejectedUntil)
+}
+
+func (pool *upstreamPool) choose(strategy balancingtype.Type, candidates []entity.Upstream, key string) entity.Upstream {
+	switch strategy {
+	case balancingtype.WEIGHTED_RANDOM:
+		total := 0
+		for _, candidate := range candidates {
+			total += upstreamWeight(candidate)
+		}
+
+		pick := rand.IntN(total)
+		for _, candidate := range candidates {
+			pick -= upstreamWeight(candidate)
+			if pick < constants.ZERO {
+				return candidate
+			}
+		}
+
+	case balancingtype.LEAST_CONNECTIONS:
+		// ties on the active/weight ratio, compared without dividing, are broken by
+		// round-robin so idle upstreams still share the traffic
+		least := []entity.Upstream{candidates[0]}
+		for _, candidate := range candidates[1:] {
+			best := least[0]
+			candidateLoad := pool.state(candidate.URL).active * upstreamWeight(best)
+			bestLoad := pool.state(best.URL).active * upstreamWeight(candidate)
+
+			if candidateLoad < bestLoad {
+				least = []entity.Upstream{candidate}
+			} else if candidateLoad == bestLoad {
+				least = append(least, candidate)
+			}
+		}
+		return pool.roundRobin(least)
+
+	case balancingtype.IP_HASH, balancingtype.COOKIE_HASH:
+		if key != "" {
+			return rendezvous(candidates, key)
+		}
+	}
+
+	return pool.roundRobin(candidates)
+}
+
+// roundRobin is the smooth weighted round-robin: every upstream gains its weight on
+// each pick and the one ahead is chosen and set back by the total, so heavier upstreams
+// are picked more often without being picked in bursts.
+func (pool *upstreamPool) roundRobin(candidates []entity.Upstream) entity.Upstream {
+	total := 0
+	var best entity.Upstream
+	var bestState *upstreamState
+
+	for _, candidate := range candidates {
+		state := pool.state(candidate.URL)
+		weight := upstreamWeight(candidate)
+
+		state.currentWeight += weight
+		total += weight
+
+		if bestState == nil || state.currentWeight > bestState.currentWeight {
+			best = candidate
+			bestState = state
+		}
+	}
+
+	bestState.currentWeight -= total
+	return best
+}
+
+// rendezvous hashes the key against every upstream and picks the highest weighted
+// score, so a key keeps its upstream as long as it stays available and only the keys
+// of an upstream that goes away are moved.
+func rendezvous(candidates []entity.Upstream, key string) entity.Upstream {
+	var best entity.Upstream
+	bestScore := math.Inf(-1)
+
+	for _, candidate := range candidates {
+		hash := fnv.New64a()
+		hash.Write([]byte(key))
+		hash.Write([]byte(candidate.URL))
+
+		// maps the hash into (0, 1)
+		uniform := (float64(hash.Sum64()>>11) + 0.5) / (1 << 53)
+		score := float64(upstreamWeight(candidate)) / -math.Log(uniform)
+
+		if score > bestScore {
+			best = candidate
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+func upstreamWeight(upstream entity.Upstream) int {
+	if upstream.Weight <= constants.ZERO {
+		return constants.ONE
+	}
+	return upstream.Weight
+}
+
+func (pool *upstreamPool) release(upstreamURL string, success bool) {
+	state := pool.state(upstreamURL)
+	state.active--
+
+	if success {
+		state.consecutiveFailures = constants.ZERO
+		return
+	}
+
+	ejectionConfig := config.ApplicationConfig.Balancing.Ejection
+	state.consecutiveFailures++
+	if ejectionConfig.ConsecutiveFailures > constants.ZERO && state.consecutiveFailures >= ejectionConfig.ConsecutiveFailures {
+		state.consecutiveFailures = constants.ZERO
+		state.ejectedUntil = time.Now().Add(ejectionConfig.Duration)
+		log.Warn(context.Background()).Msg("Ejecting upstream " + upstreamURL + " of redirect " + pool.redirect.ID + " after consecutive failures")
+	}
+}
+
+func (service *LoadBalancerService) run(ctx context.Context) {
+	ticker := time.NewTicker(HEALTH_CHECK_TICK)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			service.tick(ctx, now)
+		}
+	}
+}
+
+// tick starts the health checks that are due and forgets the pools of redirects that
+// didn't get traffic for a while.
+func (service *LoadBalancerService) tick(ctx context.Context, now time.Time) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	for id, pool := range service.pools {
+		pool.mutex.Lock()
+
+		if now.Sub(pool.lastUsed) > UPSTREAM_POOL_IDLE_TIMEOUT {
+			delete(service.pools, id)
+
+		} else if pool.redirect.Balancing != nil && pool.redirect.Balancing.HealthCheckPath != "" &&
+			!pool.checking && !now.Before(pool.nextCheck) {
+
+			interval := config.ApplicationConfig.Balancing.HealthCheck.Interval
+			if interval <= constants.ZERO {
+				interval = DEFAULT_HEALTH_CHECK_INTERVAL
+			}
+
+			pool.checking = true
+			pool.nextCheck = now.Add(interval)
+			go service.check(ctx, pool, pool.redirect)
+		}
+
+		pool.mutex.Unlock()
+	}
+}
+
+func (service *LoadBalancerService) check(ctx context.Context, pool *upstreamPool, redirect entity.Redirect) {
+	healthCheckConfig := config.ApplicationConfig.Balancing.HealthCheck
+	results := make(map[string]bool)
+
+	for _, upstream := range redirect.GetUpstreams() {
+		results[upstream.URL] = service.probe(ctx, upstream.URL, redirect.Balancing.HealthCheckPath)
+	}
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.checking = false
+	for upstreamURL, healthy := range results {
+		state := pool.state(upstreamURL)
+
+		if healthy {
+			state.checkFailures = constants.ZERO
+			state.checkSuccesses++
+			if state.unhealthy && state.checkSuccesses >= max(healthCheckConfig.HealthyThreshold, constants.ONE) {
+				state.unhealthy = false
+				log.Info(ctx).Msg("Upstream " + upstreamURL + " of redirect " + redirect.ID + " is healthy again")
+			}
+
+		} else {
+			state.checkSuccesses = constants.ZERO
+			state.checkFailures++
+			if !state.unhealthy && state.checkFailures >= max(healthCheckConfig.UnhealthyThreshold, constants.ONE) {
+				state.unhealthy = true
+				log.Warn(ctx).Msg("Upstream " + upstreamURL + " of redirect " + redirect.ID + " failed its health check")
+			}
+		}
+	}
+}
+
+// probe requests the health check path on the host of an upstream; any answer below
+// 400 counts as healthy.
+func (service *LoadBalancerService) probe(ctx context.Context, upstreamURL string, path string) bool {
+	target, err := url.Parse(upstreamURL)
+	if err != nil {
+		return false
+	}
+	target.Path = path
+	target.RawPath = ""
+	target.RawQuery = ""
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return false
+	}
+	response.Body.Close()
+
+	return response.StatusCode < http.StatusBadRequest
+}
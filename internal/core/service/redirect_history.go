@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"strconv"
+	"time"
+)
+
+// GetHistory returns a page of the revisions of a redirect, newest first. The history
+// outlives the redirect, so it is also available once the redirect is removed.
+func (service *RedirectService) GetHistory(ctx context.Context, id string, pagination request.Pagination) ([]entity.Revision, int64, *exceptions.WrappedError) {
+	normalizePagination(&pagination)
+
+	return service.revisionRepository.Find(ctx, id, pagination.Page, pagination.Size)
+}
+
+// Rollback restores a redirect to its state after a revision, re-creating it under the
+// same ID when it was removed or purged since. The restore is itself a new revision.
+func (service *RedirectService) Rollback(ctx context.Context, id string, revisionNumber int64) (entity.Redirect, *exceptions.WrappedError) {
+	revision, errw := service.revisionRepository.Get(ctx, id, revisionNumber)
+	if errw != nil {
+		return entity.Redirect{}, errw
+	}
+
+	if revision.After == nil {
+		return entity.Redirect{}, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "Revision [" + strconv.FormatInt(revisionNumber, 10) + "] removed redirect [" + id + "], roll back to an earlier one",
+		}
+	}
+
+	redirect := *revision.After
+	redirect.CreatedAt = time.Time{}
+	redirect.Version = constants.ZERO
+
+	current, errw := service.repository.Get(ctx, id)
+	if errw != nil && errw.BaseError == exceptions.RecordNotFound {
+		current, errw = service.repository.GetTrashed(ctx, id)
+	}
+
+	if errw == nil {
+		redirect.CreatedAt = current.CreatedAt
+		redirect.Version = current.Version
+	} else if errw.BaseError != exceptions.RecordNotFound {
+		return entity.Redirect{}, errw
+	}
+
+	ctx = context.WithValue(ctx, constants.RESTORED_REVISION, revisionNumber)
+	errw = service.Save(ctx, &redirect)
+	return redirect, errw
+}
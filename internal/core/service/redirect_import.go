@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"fernandoglatz/url-management/internal/core/model/response"
+	"strconv"
+	"time"
+)
+
+const MAXIMUM_IMPORT_SIZE = 10000
+
+// Import creates or updates a batch of redirects, matching the existing ones by ID or
+// by DNS and URI. Every item is checked before anything is written, and the batch is
+// only saved, all at once, when none conflicts and it isn't a dry run.
+func (service *RedirectService) Import(ctx context.Context, redirects []entity.Redirect, options request.ImportOptions) (response.ImportResponse, *exceptions.WrappedError) {
+	report := response.ImportResponse{
+		DryRun: options.DryRun,
+		Items:  []response.ImportItemResponse{},
+	}
+
+	if utils.IsEmptyStr(options.UpsertBy) {
+		options.UpsertBy = request.UPSERT_BY_ID
+	}
+
+	if options.UpsertBy != request.UPSERT_BY_ID && options.UpsertBy != request.UPSERT_BY_DNS {
+		return report, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "Invalid upsertBy [" + options.UpsertBy + "], use one of [id, dns]",
+		}
+	}
+
+	if len(redirects) > MAXIMUM_IMPORT_SIZE {
+		return report, &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "An import is limited to " + strconv.Itoa(MAXIMUM_IMPORT_SIZE) + " redirects",
+		}
+	}
+
+	batchIDs := make(map[string]bool)
+	for index := range redirects {
+		redirect := &redirects[index]
+		normalizeRedirect(redirect)
+
+		item := response.ImportItemResponse{Index: index, Action: response.IMPORT_ACTION_CREATE}
+		errw := service.resolveExisting(ctx, redirect, options.UpsertBy)
+		if errw != nil && errw.BaseError != exceptions.Conflict {
+			return report, errw
+		}
+
+		if errw != nil {
+			item.Action = response.IMPORT_ACTION_CONFLICT
+			item.Message = errw.GetMessage()
+		} else if !redirect.CreatedAt.IsZero() {
+			item.Action = response.IMPORT_ACTION_UPDATE
+		}
+
+		if utils.IsNotEmptyStr(redirect.ID) {
+			batchIDs[redirect.ID] = true
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	firstByID := make(map[string]int)
+	firstByRoute := make(map[string]int)
+
+	for index := range redirects {
+		redirect := &redirects[index]
+		item := &report.Items[index]
+		item.ID, item.DNS, item.URI = redirect.ID, redirect.DNS, redirect.URI
+
+		if item.Action == response.IMPORT_ACTION_CONFLICT {
+			continue
+		}
+
+		if utils.IsNotEmptyStr(redirect.ID) {
+			if first, found := firstByID[redirect.ID]; found {
+				rejectImportItem(item, "Redirect ["+redirect.ID+"] is repeated, first at index "+strconv.Itoa(first), nil)
+				continue
+			}
+			firstByID[redirect.ID] = index
+		}
+
+		if utils.IsNotEmptyStr(redirect.DNS) {
+			route := redirect.DNS + redirect.URI + " " + redirect.Match.String()
+			if first, found := firstByRoute[route]; found {
+				rejectImportItem(item, "DNS ["+redirect.DNS+redirect.URI+"] is repeated, first at index "+strconv.Itoa(first), nil)
+				continue
+			}
+			firstByRoute[route] = index
+		}
+
+		if errw := validateRedirect(redirect); errw != nil {
+			rejectImportItem(item, errw.GetMessage(), errw.Details)
+			continue
+		}
+
+		errw := service.validateUniqueness(ctx, redirect, batchIDs)
+		if errw != nil && errw.BaseError != exceptions.Conflict {
+			return report, errw
+		}
+		if errw != nil {
+			rejectImportItem(item, errw.GetMessage(), errw.Details)
+			continue
+		}
+
+		applyDefaults(redirect)
+	}
+
+	batch := make([]*entity.Redirect, constants.ZERO, len(redirects))
+	for index, item := range report.Items {
+		switch item.Action {
+		case response.IMPORT_ACTION_CREATE:
+			report.Created++
+		case response.IMPORT_ACTION_UPDATE:
+			report.Updated++
+		default:
+			report.Conflicts++
+		}
+		batch = append(batch, &redirects[index])
+	}
+
+	if options.DryRun || report.Conflicts > constants.ZERO {
+		return report, nil
+	}
+
+	errw := service.repository.SaveMany(ctx, batch)
+	if errw != nil {
+		return report, errw
+	}
+
+	for index, redirect := range batch {
+		report.Items[index].ID = redirect.ID
+	}
+
+	report.Applied = true
+	return report, nil
+}
+
+// resolveExisting looks up the redirect an imported one replaces, taking its ID,
+// creation date and version. Imported timestamps and versions are ignored.
+func (service *RedirectService) resolveExisting(ctx context.Context, redirect *entity.Redirect, upsertBy string) *exceptions.WrappedError {
+	redirect.CreatedAt = time.Time{}
+	redirect.UpdatedAt = time.Time{}
+	redirect.Version = constants.ZERO
+
+	if upsertBy == request.UPSERT_BY_DNS {
+		if utils.IsEmptyStr(redirect.DNS) {
+			return &exceptions.WrappedError{
+				BaseError: exceptions.Conflict,
+				Message:   "DNS is required to upsert by DNS",
+			}
+		}
+
+		existing, errw := service.repository.GetAllByDNS(ctx, []string{redirect.DNS})
+		if errw != nil {
+			return errw
+		}
+
+		for _, other := range existing {
+			if other.URI != redirect.URI || other.Match != redirect.Match {
+				continue
+			}
+
+			if utils.IsNotEmptyStr(redirect.ID) && redirect.ID != other.ID {
+				return &exceptions.WrappedError{
+					BaseError: exceptions.Conflict,
+					Message:   "DNS [" + redirect.DNS + redirect.URI + "] belongs to redirect [" + other.ID + "], not [" + redirect.ID + "]",
+				}
+			}
+
+			redirect.ID = other.ID
+			redirect.CreatedAt = other.CreatedAt
+			redirect.Version = other.Version
+			return nil
+		}
+	}
+
+	if utils.IsEmptyStr(redirect.ID) {
+		return nil
+	}
+
+	existing, errw := service.repository.Get(ctx, redirect.ID)
+	if errw != nil && errw.BaseError == exceptions.RecordNotFound {
+		return service.rejectTrashedID(ctx, redirect.ID)
+	} else if errw != nil {
+		return errw
+	}
+
+	redirect.CreatedAt = existing.CreatedAt
+	redirect.Version = existing.Version
+	return nil
+}
+
+// rejectTrashedID rejects importing a redirect under the ID of one in the trash, which
+// would otherwise fail the whole batch on the unique id index.
+func (service *RedirectService) rejectTrashedID(ctx context.Context, id string) *exceptions.WrappedError {
+	_, errw := service.repository.GetTrashed(ctx, id)
+	if errw != nil && errw.BaseError == exceptions.RecordNotFound {
+		return nil
+	} else if errw != nil {
+		return errw
+	}
+
+	return &exceptions.WrappedError{
+		BaseError: exceptions.Conflict,
+		Message:   "Redirect [" + id + "] is in the trash, restore or purge it first",
+	}
+}
+
+func rejectImportItem(item *response.ImportItemResponse, message string, details []exceptions.FieldError) {
+	item.Action = response.IMPORT_ACTION_CONFLICT
+	item.Message = message
+	item.Details = details
+}
+
+// Export returns every redirect matching the filter, read page by page so large
+// exports don't hold a single cursor open for long.
+func (service *RedirectService) Export(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, *exceptions.WrappedError) {
+	if utils.IsBlankStr(filter.Sort) {
+		filter.Sort = "id"
+	}
+
+	exported := []entity.Redirect{}
+	for page := constants.ONE; ; page++ {
+		filter.Page = page
+		filter.Size = MAXIMUM_PAGE_SIZE
+
+		redirects, _, errw := service.Find(ctx, filter)
+		if errw != nil {
+			return exported, errw
+		}
+
+		exported = append(exported, redirects...)
+		if len(redirects) < MAXIMUM_PAGE_SIZE {
+			return exported, nil
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fernandoglatz/url-management/internal/core/entity"
+	matchtype "fernandoglatz/url-management/internal/core/entity/match"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var compiledPatterns sync.Map
+
+// matchRedirect picks the rule of a host that best matches the request path. The
+// longest matching URI wins; on equal length an EXACT rule beats a PREFIX rule,
+// which beats a REGEX rule. REGEX rules are ranked by their literal prefix.
+func matchRedirect(redirects []entity.Redirect, path string) (entity.Redirect, bool) {
+	var best entity.Redirect
+	bestLength := -1
+	found := false
+
+	for _, redirect := range redirects {
+		length, ok := matchPath(redirect, path)
+		if !ok {
+			continue
+		}
+
+		if !found || length > bestLength || (length == bestLength && matchPriority(redirect.Match) > matchPriority(best.Match)) {
+			best = redirect
+			bestLength = length
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+func matchPath(redirect entity.Redirect, path string) (int, bool) {
+	uri := redirect.URI
+
+	switch redirect.Match {
+	case matchtype.EXACT:
+		return len(uri), path == uri
+
+	case matchtype.REGEX:
+		pattern, err := compilePattern(uri)
+		if err != nil || !pattern.MatchString(path) {
+			return 0, false
+		}
+		literal, _ := pattern.LiteralPrefix()
+		return len(literal), true
+
+	default:
+		prefix := strings.TrimSuffix(uri, "*")
+		if prefix == "" || prefix == "/" {
+			return len(prefix), true
+		}
+		if !strings.HasPrefix(path, prefix) {
+			return 0, false
+		}
+		// "/api" matches "/api" and "/api/users" but not "/apikeys"
+		if len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/' {
+			return len(prefix), true
+		}
+		return 0, false
+	}
+}
+
+func matchPriority(match matchtype.Type) int {
+	switch match {
+	case matchtype.EXACT:
+		return 2
+	case matchtype.PREFIX:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if compiled, ok := compiledPatterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledPatterns.Store(pattern, compiled)
+	return compiled, nil
+}
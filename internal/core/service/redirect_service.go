@@ -2,18 +2,35 @@ package service
 
 import (
 	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
 	"fernandoglatz/url-management/internal/core/entity"
+	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
+	"fernandoglatz/url-management/internal/core/model/request"
 	"fernandoglatz/url-management/internal/core/port/repository"
+	"slices"
+	"strings"
+	"time"
 )
 
+const (
+	DEFAULT_PAGE_SIZE = 20
+	MAXIMUM_PAGE_SIZE = 100
+	DEFAULT_SORT      = "-createdAt"
+)
+
+var sortableFields = []string{"id", "createdAt", "updatedAt", "deletedAt", "dns", "uri", "destination", "type"}
+
 type RedirectService struct {
-	repository repository.IRedirectRepository
+	repository         repository.IRedirectRepository
+	revisionRepository repository.IRevisionRepository
 }
 
-func NewRedirectService(repository repository.IRedirectRepository) *RedirectService {
+func NewRedirectService(repository repository.IRedirectRepository, revisionRepository repository.IRevisionRepository) *RedirectService {
 	return &RedirectService{
-		repository: repository,
+		repository:         repository,
+		revisionRepository: revisionRepository,
 	}
 }
 
@@ -21,18 +38,152 @@ func (service *RedirectService) Get(ctx context.Context, id string) (entity.Redi
 	return service.repository.Get(ctx, id)
 }
 
-func (service *RedirectService) GetByDNS(ctx context.Context, dns string) (entity.Redirect, *exceptions.WrappedError) {
-	return service.repository.GetByDNS(ctx, dns)
+// GetByDNS resolves the redirect serving a host and path. Exact hostnames win over
+// wildcard entries (*.example.com), deeper wildcards win over shallower ones and the
+// catch-all "*" is used last; path rules are only compared within the same entry.
+// Redirects whose activation window hasn't started yet are ignored.
+func (service *RedirectService) GetByDNS(ctx context.Context, dns string, path string) (entity.Redirect, *exceptions.WrappedError) {
+	candidates := utils.DNSCandidates(dns)
+
+	redirects, errw := service.repository.GetAllByDNS(ctx, candidates)
+	if errw != nil {
+		return entity.Redirect{}, errw
+	}
+
+	now := time.Now()
+	redirectsByDNS := make(map[string][]entity.Redirect)
+	for _, redirect := range redirects {
+		if !redirect.IsActive(now) {
+			continue
+		}
+
+		key := strings.ToLower(redirect.DNS)
+		redirectsByDNS[key] = append(redirectsByDNS[key], redirect)
+	}
+
+	for _, candidate := range candidates {
+		redirect, found := matchRedirect(redirectsByDNS[candidate], path)
+		if found {
+			return redirect, nil
+		}
+	}
+
+	return entity.Redirect{}, &exceptions.WrappedError{
+		BaseError: exceptions.RecordNotFound,
+	}
 }
 
 func (service *RedirectService) GetAll(ctx context.Context) ([]entity.Redirect, *exceptions.WrappedError) {
 	return service.repository.GetAll(ctx)
 }
 
+// Find normalizes the paging and sorting of a filter before querying: pages start
+// at 1, sizes default to DEFAULT_PAGE_SIZE up to MAXIMUM_PAGE_SIZE, and sorting
+// defaults to the newest redirects first.
+func (service *RedirectService) Find(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	errw := normalizeFilter(&filter, DEFAULT_SORT)
+	if errw != nil {
+		return []entity.Redirect{}, constants.ZERO, errw
+	}
+
+	return service.repository.Find(ctx, filter)
+}
+
+// normalizePagination starts pages at 1 and bounds their size, defaulting it to
+// DEFAULT_PAGE_SIZE.
+func normalizePagination(pagination *request.Pagination) {
+	if pagination.Page < constants.ONE {
+		pagination.Page = constants.ONE
+	}
+
+	if pagination.Size < constants.ONE {
+		pagination.Size = DEFAULT_PAGE_SIZE
+	} else if pagination.Size > MAXIMUM_PAGE_SIZE {
+		pagination.Size = MAXIMUM_PAGE_SIZE
+	}
+}
+
+func normalizeFilter(filter *request.RedirectFilter, defaultSort string) *exceptions.WrappedError {
+	normalizePagination(&filter.Pagination)
+
+	if utils.IsBlankStr(filter.Sort) {
+		filter.Sort = defaultSort
+	}
+
+	for _, field := range strings.Split(filter.Sort, ",") {
+		field = strings.TrimPrefix(strings.TrimSpace(field), "-")
+		if !slices.Contains(sortableFields, field) {
+			return &exceptions.WrappedError{
+				BaseError: exceptions.InvalidParameter,
+				Message:   "Invalid sort field [" + field + "], use one of [" + strings.Join(sortableFields, ", ") + "]",
+			}
+		}
+	}
+
+	if utils.IsNotEmptyStr(filter.Type) {
+		filter.Type = strings.ToUpper(filter.Type)
+		if _, ok := redirecttype.Parse(filter.Type); !ok {
+			return &exceptions.WrappedError{
+				BaseError: exceptions.InvalidParameter,
+				Message:   "Invalid type [" + filter.Type + "]",
+			}
+		}
+	}
+
+	tags := []string{}
+	for _, tag := range filter.Tags {
+		for _, value := range strings.Split(tag, ",") {
+			if utils.IsNotBlankStr(value) {
+				tags = append(tags, strings.TrimSpace(value))
+			}
+		}
+	}
+	filter.Tags = tags
+
+	return nil
+}
+
+// RegisterClick counts a click towards the click limit of a redirect and returns the
+// total clicks so far.
+func (service *RedirectService) RegisterClick(ctx context.Context, redirect entity.Redirect) (int64, *exceptions.WrappedError) {
+	return service.repository.IncrementClicks(ctx, redirect.ID)
+}
+
 func (service *RedirectService) Save(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	normalizeRedirect(redirect)
+
+	errw := validateRedirect(redirect)
+	if errw != nil {
+		return errw
+	}
+
+	errw = service.validateUniqueness(ctx, redirect, nil)
+	if errw != nil {
+		return errw
+	}
+
+	applyDefaults(redirect)
 	return service.repository.Save(ctx, redirect)
 }
 
+func normalizeRedirect(redirect *entity.Redirect) {
+	redirect.DNS = strings.ToLower(strings.TrimSpace(redirect.DNS))
+	redirect.Destination = strings.TrimSpace(redirect.Destination)
+}
+
+// applyDefaults fills the destination of redirects defined by their upstreams or
+// variants, so listings and filters by destination keep working for them.
+func applyDefaults(redirect *entity.Redirect) {
+	if utils.IsBlankStr(redirect.Destination) && len(redirect.Upstreams) > constants.ZERO {
+		redirect.Destination = redirect.Upstreams[0].URL
+	}
+
+	if utils.IsBlankStr(redirect.Destination) && len(redirect.Variants) > constants.ZERO {
+		redirect.Destination = redirect.Variants[0].Destination
+	}
+}
+
+// Remove moves a redirect to the trash, from where it can be restored until it's purged.
 func (service *RedirectService) Remove(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError {
 	return service.repository.Remove(ctx, redirect)
 }
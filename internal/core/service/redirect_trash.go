@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
+)
+
+const DEFAULT_TRASH_SORT = "-deletedAt"
+
+// FindTrash lists the removed redirects like Find, the most recently removed first.
+func (service *RedirectService) FindTrash(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	errw := normalizeFilter(&filter, DEFAULT_TRASH_SORT)
+	if errw != nil {
+		return []entity.Redirect{}, constants.ZERO, errw
+	}
+
+	return service.repository.FindTrash(ctx, filter)
+}
+
+// Restore takes a redirect out of the trash, as long as no other redirect has taken
+// its route in the meantime.
+func (service *RedirectService) Restore(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	redirect, errw := service.repository.GetTrashed(ctx, id)
+	if errw != nil {
+		return redirect, errw
+	}
+
+	errw = service.validateUniqueness(ctx, &redirect, nil)
+	if errw != nil {
+		return redirect, errw
+	}
+
+	errw = service.repository.Restore(ctx, &redirect)
+	return redirect, errw
+}
+
+// Purge deletes a redirect in the trash for good. Its history is kept.
+func (service *RedirectService) Purge(ctx context.Context, id string) *exceptions.WrappedError {
+	redirect, errw := service.repository.GetTrashed(ctx, id)
+	if errw != nil {
+		return errw
+	}
+
+	return service.repository.Purge(ctx, redirect)
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	fallbacktype "fernandoglatz/url-management/internal/core/entity/fallback"
+	matchtype "fernandoglatz/url-management/internal/core/entity/match"
+	redirecttype "fernandoglatz/url-management/internal/core/entity/redirect"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	MAXIMUM_HOSTNAME_LENGTH = 253
+	TOTAL_VARIANT_WEIGHT    = 100
+)
+
+var hostnameLabelPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+var redirectStatusCodes = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusSeeOther,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+var variantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,8}(?:-[A-Za-z0-9]{1,8})*$`)
+var countryCodePattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+type redirectValidator struct {
+	details []exceptions.FieldError
+}
+
+func (validator *redirectValidator) reject(field string, message string) {
+	validator.details = append(validator.details, exceptions.FieldError{
+		Field:   field,
+		Message: message,
+	})
+}
+
+func (validator *redirectValidator) err() *exceptions.WrappedError {
+	if len(validator.details) == constants.ZERO {
+		return nil
+	}
+
+	return &exceptions.WrappedError{
+		BaseError: exceptions.ValidationError,
+		Details:   validator.details,
+	}
+}
+
+// validateRedirect checks the fields of a redirect before it is saved, reporting every
+// invalid field at once.
+func validateRedirect(redirect *entity.Redirect) *exceptions.WrappedError {
+	validator := &redirectValidator{}
+
+	isVanityCode := redirect.CreatedAt.IsZero() && utils.IsNotEmptyStr(redirect.ID)
+	if isVanityCode {
+		if message := validateShortCode(redirect.ID); utils.IsNotEmptyStr(message) {
+			validator.reject("id", message)
+		}
+	}
+
+	if (len(redirect.Upstreams) == constants.ZERO && len(redirect.Variants) == constants.ZERO) || utils.IsNotBlankStr(redirect.Destination) {
+		validator.validateDestination("destination", redirect.Destination, redirect.Type)
+	}
+	validator.validateUpstreams(redirect.Upstreams, redirect.Type)
+	validator.validateVariants(redirect)
+	validator.validateRules(redirect.Rules, redirect.Type)
+	validator.validateBalancing(redirect.Balancing)
+	validator.validateDNS(redirect.DNS)
+	validator.validateURI(redirect.URI, redirect.Match)
+	validator.validateExpiration(redirect)
+	validator.validateRedirectAnswer(redirect)
+	validator.validateHeaders(redirect.Headers)
+
+	return validator.err()
+}
+
+func (validator *redirectValidator) validateURL(field string, value string) {
+	if utils.IsBlankStr(value) {
+		validator.reject(field, "is required")
+		return
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		validator.reject(field, "is not a valid URL")
+		return
+	}
+
+	if !isAllowedScheme(parsed.Scheme) {
+		allowedSchemes := strings.Join(config.ApplicationConfig.Redirect.Validation.AllowedSchemes, ", ")
+		validator.reject(field, "scheme must be one of ["+allowedSchemes+"]")
+		return
+	}
+
+	if utils.IsEmptyStr(parsed.Hostname()) {
+		validator.reject(field, "must be an absolute URL with a host")
+	}
+}
+
+// validateDestination validates a destination URL, expanding the placeholders first
+// when the destination is answered by a REDIRECT, the only type supporting them.
+func (validator *redirectValidator) validateDestination(field string, value string, redirectType redirecttype.Type) {
+	if redirectType == redirecttype.REDIRECT {
+		value = entity.ExpandDestination(value, "/path", "query", "example.com")
+	}
+
+	validator.validateURL(field, value)
+}
+
+func (validator *redirectValidator) validateUpstreams(upstreams []entity.Upstream, redirectType redirecttype.Type) {
+	seen := make(map[string]bool)
+
+	for index, upstream := range upstreams {
+		field := "upstreams[" + strconv.Itoa(index) + "]"
+
+		validator.validateDestination(field+".url", upstream.URL, redirectType)
+		if seen[upstream.URL] {
+			validator.reject(field+".url", "is repeated")
+		}
+		seen[upstream.URL] = true
+
+		if upstream.Weight < constants.ZERO {
+			validator.reject(field+".weight", "must not be negative")
+		}
+	}
+}
+
+// validateVariants checks the variants of an A/B split, which only REDIRECT records
+// support: the weights are percentages of the traffic and must add up to 100.
+func (validator *redirectValidator) validateVariants(redirect *entity.Redirect) {
+	if len(redirect.Variants) == constants.ZERO {
+		return
+	}
+
+	if redirect.Type != redirecttype.REDIRECT {
+		validator.reject("variants", "are only supported by REDIRECT redirects")
+		return
+	}
+
+	if len(redirect.Upstreams) > constants.ZERO {
+		validator.reject("variants", "can't be combined with upstreams")
+	}
+
+	seen := make(map[string]bool)
+	total := 0
+
+	for index, variant := range redirect.Variants {
+		field := "variants[" + strconv.Itoa(index) + "]"
+
+		if !variantNamePattern.MatchString(variant.Name) {
+			validator.reject(field+".name", "must have 1 to 32 letters, digits, '-' or '_'")
+		} else if seen[variant.Name] {
+			validator.reject(field+".name", "is repeated")
+		}
+		seen[variant.Name] = true
+
+		validator.validateDestination(field+".destination", variant.Destination, redirecttype.REDIRECT)
+
+		if variant.Weight < constants.ZERO {
+			validator.reject(field+".weight", "must not be negative")
+		}
+		total += variant.Weight
+	}
+
+	if total != TOTAL_VARIANT_WEIGHT {
+		validator.reject("variants", "weights must add up to "+strconv.Itoa(TOTAL_VARIANT_WEIGHT))
+	}
+}
+
+func (validator *redirectValidator) validateRules(rules []entity.Rule, redirectType redirecttype.Type) {
+	for index, rule := range rules {
+		field := "rules[" + strconv.Itoa(index) + "]"
+		conditions := rule.When
+
+		ruleType := redirectType
+		if rule.Type != nil {
+			ruleType = *rule.Type
+		}
+		validator.validateDestination(field+".destination", rule.Destination, ruleType)
+
+		if len(conditions.Devices) == constants.ZERO && len(conditions.Languages) == constants.ZERO &&
+			len(conditions.Countries) == constants.ZERO && len(conditions.Query) == constants.ZERO &&
+			len(conditions.Headers) == constants.ZERO && conditions.Time == nil {
+			validator.reject(field+".when", "must have at least one condition")
+		}
+
+		for _, language := range conditions.Languages {
+			if !languageTagPattern.MatchString(language) {
+				validator.reject(field+".when.languages", "["+language+"] is not a valid language tag")
+			}
+		}
+
+		for _, country := range conditions.Countries {
+			if !countryCodePattern.MatchString(country) {
+				validator.reject(field+".when.countries", "["+country+"] is not an ISO 3166-1 alpha-2 code")
+			}
+		}
+
+		for name := range conditions.Headers {
+			if !httpguts.ValidHeaderFieldName(name) {
+				validator.reject(field+".when.headers", "["+name+"] is not a valid header name")
+			}
+		}
+
+		if conditions.Time != nil {
+			validator.validateTimeWindow(field+".when.time", *conditions.Time)
+		}
+	}
+}
+
+func (validator *redirectValidator) validateTimeWindow(field string, window entity.TimeWindow) {
+	if _, err := time.Parse(TIME_OF_DAY_FORMAT, window.From); err != nil {
+		validator.reject(field+".from", "must be a time of day as HH:MM")
+	}
+
+	if _, err := time.Parse(TIME_OF_DAY_FORMAT, window.To); err != nil {
+		validator.reject(field+".to", "must be a time of day as HH:MM")
+	}
+
+	for _, day := range window.Days {
+		if _, ok := weekdayNames[strings.ToUpper(day)]; !ok {
+			validator.reject(field+".days", "["+day+"] must be one of [MON, TUE, WED, THU, FRI, SAT, SUN]")
+		}
+	}
+
+	if utils.IsNotEmptyStr(window.Timezone) {
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			validator.reject(field+".timezone", "is not a known timezone")
+		}
+	}
+}
+
+func (validator *redirectValidator) validateRedirectAnswer(redirect *entity.Redirect) {
+	if redirect.StatusCode != constants.ZERO && !slices.Contains(redirectStatusCodes, redirect.StatusCode) {
+		validator.reject("statusCode", "must be one of [301, 302, 303, 307, 308]")
+	}
+
+	if redirect.UTM != nil && len(redirect.UTM.Parameters()) == constants.ZERO {
+		validator.reject("utm", "must set at least one parameter")
+	}
+}
+
+func (validator *redirectValidator) validateBalancing(balancing *entity.Balancing) {
+	if balancing == nil {
+		return
+	}
+
+	if utils.IsNotEmptyStr(balancing.HealthCheckPath) && !strings.HasPrefix(balancing.HealthCheckPath, "/") {
+		validator.reject("balancing.healthCheckPath", "must start with '/'")
+	}
+
+	if utils.IsNotEmptyStr(balancing.Cookie) && !httpguts.ValidHeaderFieldName(balancing.Cookie) {
+		validator.reject("balancing.cookie", "is not a valid cookie name")
+	}
+}
+
+func (validator *redirectValidator) validateDNS(dns string) {
+	if utils.IsEmptyStr(dns) || dns == "*" {
+		return
+	}
+
+	if !isValidHostname(strings.TrimPrefix(dns, "*.")) {
+		validator.reject("dns", "is not a valid hostname, wildcards are only allowed as a leading '*.'")
+	}
+}
+
+func (validator *redirectValidator) validateURI(uri string, match matchtype.Type) {
+	switch match {
+	case matchtype.REGEX:
+		if _, err := regexp.Compile(uri); err != nil {
+			validator.reject("uri", "is not a valid regular expression: "+err.Error())
+		}
+
+	default:
+		if utils.IsNotEmptyStr(uri) && !strings.HasPrefix(uri, "/") {
+			validator.reject("uri", "must start with '/'")
+		}
+	}
+}
+
+func (validator *redirectValidator) validateExpiration(redirect *entity.Redirect) {
+	if redirect.ActiveFrom != nil && redirect.ExpiresAt != nil && !redirect.ExpiresAt.After(*redirect.ActiveFrom) {
+		validator.reject("expiresAt", "must be after activeFrom")
+	}
+
+	if redirect.MaxClicks < constants.ZERO {
+		validator.reject("maxClicks", "must not be negative")
+	}
+
+	fallback := redirect.Fallback
+	if fallback == nil {
+		return
+	}
+
+	switch fallback.Type {
+	case fallbacktype.REDIRECT:
+		parsed, err := url.Parse(fallback.URL)
+		if err != nil || !isAllowedScheme(parsed.Scheme) || utils.IsEmptyStr(parsed.Hostname()) {
+			validator.reject("fallback.url", "must be an absolute URL with an allowed scheme")
+		}
+
+	case fallbacktype.PAGE:
+		if utils.IsBlankStr(fallback.Page) {
+			validator.reject("fallback.page", "is required")
+		}
+	}
+}
+
+func (validator *redirectValidator) validateHeaders(policy *entity.HeaderPolicy) {
+	if policy == nil {
+		return
+	}
+
+	validator.validateHeaderRules("headers.request", policy.Request)
+	validator.validateHeaderRules("headers.response", policy.Response)
+}
+
+func (validator *redirectValidator) validateHeaderRules(field string, rules entity.HeaderRules) {
+	for _, values := range []map[string]string{rules.Set, rules.Add} {
+		for name, value := range values {
+			if !httpguts.ValidHeaderFieldName(name) {
+				validator.reject(field, "["+name+"] is not a valid header name")
+			} else if !httpguts.ValidHeaderFieldValue(value) {
+				validator.reject(field, "value of ["+name+"] is not a valid header value")
+			}
+		}
+	}
+
+	for _, names := range [][]string{rules.Remove, rules.Keep} {
+		for _, name := range names {
+			if !httpguts.ValidHeaderFieldName(name) {
+				validator.reject(field, "["+name+"] is not a valid header name")
+			}
+		}
+	}
+}
+
+func isAllowedScheme(scheme string) bool {
+	return utils.IsNotEmptyStr(scheme) && containsFold(config.ApplicationConfig.Redirect.Validation.AllowedSchemes, scheme)
+}
+
+func isValidHostname(hostname string) bool {
+	if utils.IsEmptyStr(hostname) || len(hostname) > MAXIMUM_HOSTNAME_LENGTH {
+		return false
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		if !hostnameLabelPattern.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateUniqueness rejects a redirect whose host and path rule are already used by
+// another redirect, since only one of them could ever be served. The stored redirects
+// in batchIDs are skipped, as they are imported along with it and may be changing their
+// own host or path: the import checks the routes of the batch against each other.
+func (service *RedirectService) validateUniqueness(ctx context.Context, redirect *entity.Redirect, batchIDs map[string]bool) *exceptions.WrappedError {
+	if utils.IsEmptyStr(redirect.DNS) {
+		return nil
+	}
+
+	redirects, errw := service.repository.GetAllByDNS(ctx, []string{redirect.DNS})
+	if errw != nil {
+		return errw
+	}
+
+	for _, other := range redirects {
+		if other.ID != redirect.ID && !batchIDs[other.ID] && other.URI == redirect.URI && other.Match == redirect.Match {
+			return &exceptions.WrappedError{
+				BaseError: exceptions.Conflict,
+				Message:   "DNS [" + redirect.DNS + redirect.URI + "] is already used by redirect [" + other.ID + "]",
+				Details: []exceptions.FieldError{
+					{Field: "dns", Message: "is already used by redirect [" + other.ID + "]"},
+				},
+			}
+		}
+	}
+
+	// a trashed redirect keeps its route, so that restoring it never collides
+	trashed, errw := service.repository.GetTrashedByDNS(ctx, []string{redirect.DNS})
+	if errw != nil {
+		return errw
+	}
+
+	for _, other := range trashed {
+		if other.ID != redirect.ID && other.URI == redirect.URI && other.Match == redirect.Match {
+			return &exceptions.WrappedError{
+				BaseError: exceptions.Conflict,
+				Message:   "DNS [" + redirect.DNS + redirect.URI + "] is used by redirect [" + other.ID + "] in the trash, restore or purge it first",
+				Details: []exceptions.FieldError{
+					{Field: "dns", Message: "is used by redirect [" + other.ID + "] in the trash"},
+				},
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/entity"
+	devicetype "fernandoglatz/url-management/internal/core/entity/device"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TIME_OF_DAY_FORMAT = "15:04"
+
+var weekdayNames = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "facebookexternalhit", "embedly", "preview", "curl/", "wget/", "python-requests"}
+
+// ResolveRule returns the redirect as resolved by the first of its rules matching the
+// visitor, or the redirect itself when none does.
+func (service *RedirectService) ResolveRule(redirect entity.Redirect, visitor request.Visitor) entity.Redirect {
+	if len(redirect.Rules) == constants.ZERO {
+		return redirect
+	}
+
+	matcher := &ruleMatcher{visitor: visitor}
+	for _, rule := range redirect.Rules {
+		if matcher.matches(rule.When) {
+			return redirect.WithRule(rule)
+		}
+	}
+
+	return redirect
+}
+
+// ruleMatcher evaluates rules against a visitor, working out its device, language and
+// country only once and only when a rule needs them.
+type ruleMatcher struct {
+	visitor request.Visitor
+
+	devices  []devicetype.Type
+	language *string
+	country  *string
+}
+
+func (matcher *ruleMatcher) matches(conditions entity.RuleConditions) bool {
+	if len(conditions.Devices) > constants.ZERO && !matcher.matchesDevice(conditions.Devices) {
+		return false
+	}
+
+	if len(conditions.Languages) > constants.ZERO && !matcher.matchesLanguage(conditions.Languages) {
+		return false
+	}
+
+	if len(conditions.Countries) > constants.ZERO && !matcher.matchesCountry(conditions.Countries) {
+		return false
+	}
+
+	for name, value := range conditions.Query {
+		if !matchesValue(matcher.visitor.Query[name], value) {
+			return false
+		}
+	}
+
+	for name, value := range conditions.Headers {
+		if !matchesValue(matcher.visitor.Header.Values(name), value) {
+			return false
+		}
+	}
+
+	if conditions.Time != nil && !matchesTime(*conditions.Time, matcher.visitor.Time) {
+		return false
+	}
+
+	return true
+}
+
+func (matcher *ruleMatcher) matchesDevice(devices []devicetype.Type) bool {
+	if matcher.devices == nil {
+		matcher.devices = classifyDevice(matcher.visitor.Header.Get("User-Agent"))
+	}
+
+	for _, device := range devices {
+		if slices.Contains(matcher.devices, device) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (matcher *ruleMatcher) matchesLanguage(languages []string) bool {
+	if matcher.language == nil {
+		language := preferredLanguage(matcher.visitor.Header.Get("Accept-Language"))
+		matcher.language = &language
+	}
+
+	if utils.IsEmptyStr(*matcher.language) {
+		return false
+	}
+
+	for _, language := range languages {
+		if strings.EqualFold(*matcher.language, language) ||
+			strings.HasPrefix(strings.ToLower(*matcher.language), strings.ToLower(language)+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (matcher *ruleMatcher) matchesCountry(countries []string) bool {
+	if matcher.country == nil {
+		country := utils.GeoIPDatabase.Country(matcher.visitor.IP)
+		matcher.country = &country
+	}
+
+	if utils.IsEmptyStr(*matcher.country) {
+		return false
+	}
+
+	for _, country := range countries {
+		if strings.EqualFold(*matcher.country, country) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesValue matches a query parameter or header: an empty expected value only
+// requires it to be present.
+func matchesValue(values []string, expected string) bool {
+	if len(values) == constants.ZERO {
+		return false
+	}
+
+	return utils.IsEmptyStr(expected) || slices.Contains(values, expected)
+}
+
+func matchesTime(window entity.TimeWindow, now time.Time) bool {
+	timezone := window.Timezone
+	if utils.IsEmptyStr(timezone) {
+		timezone = utils.GetTimezone()
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return false
+	}
+
+	from, errFrom := time.Parse(TIME_OF_DAY_FORMAT, window.From)
+	to, errTo := time.Parse(TIME_OF_DAY_FORMAT, window.To)
+	if errFrom != nil || errTo != nil {
+		return false
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	fromMinute := from.Hour()*60 + from.Minute()
+	toMinute := to.Hour()*60 + to.Minute()
+
+	// a window past midnight belongs to the day it started
+	weekday := local.Weekday()
+	inWindow := false
+
+	switch {
+	case fromMinute <= toMinute:
+		inWindow = minute >= fromMinute && minute < toMinute
+	case minute >= fromMinute:
+		inWindow = true
+	case minute < toMinute:
+		inWindow = true
+		weekday = (weekday + 6) % 7
+	}
+
+	if !inWindow || len(window.Days) == constants.ZERO {
+		return inWindow
+	}
+
+	for _, day := range window.Days {
+		if weekdayNames[strings.ToUpper(day)] == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// classifyDevice returns the device classes of a User-Agent, e.g. an iPhone is both
+// IOS and MOBILE. Anything not recognized as a bot or a handheld is a DESKTOP.
+func classifyDevice(userAgent string) []devicetype.Type {
+	lower := strings.ToLower(userAgent)
+
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			return []devicetype.Type{devicetype.BOT}
+		}
+	}
+
+	devices := []devicetype.Type{}
+
+	switch {
+	case strings.Contains(lower, "ipad"):
+		devices = append(devices, devicetype.IOS, devicetype.TABLET)
+
+	case strings.Contains(lower, "iphone"), strings.Contains(lower, "ipod"):
+		devices = append(devices, devicetype.IOS, devicetype.MOBILE)
+
+	case strings.Contains(lower, "android"):
+		devices = append(devices, devicetype.ANDROID)
+		if strings.Contains(lower, "mobile") {
+			devices = append(devices, devicetype.MOBILE)
+		} else {
+			devices = append(devices, devicetype.TABLET)
+		}
+
+	case strings.Contains(lower, "tablet"):
+		devices = append(devices, devicetype.TABLET)
+
+	case strings.Contains(lower, "mobile"), strings.Contains(lower, "windows phone"):
+		devices = append(devices, devicetype.MOBILE)
+
+	default:
+		devices = append(devices, devicetype.DESKTOP)
+	}
+
+	return devices
+}
+
+// preferredLanguage returns the language of an Accept-Language header with the highest
+// quality value, the first one on ties, or "" when none is acceptable.
+func preferredLanguage(acceptLanguage string) string {
+	preferred := ""
+	preferredWeight := 0.0
+
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		name = strings.TrimSpace(name)
+		if utils.IsEmptyStr(name) || name == "*" {
+			continue
+		}
+
+		weight := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+
+		if weight > preferredWeight {
+			preferred = name
+			preferredWeight = weight
+		}
+	}
+
+	return preferred
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"strings"
+)
+
+// Path segments already used by the application routes, including the static routes
+// under /redirect that would shadow /redirect/{id}.
+var builtinReservedWords = []string{
+	"redirect",
+	"authentication",
+	"health",
+	"metrics",
+	"swagger-ui",
+	"__cdn",
+	"__cdnp",
+	"export",
+	"import",
+	"trash",
+}
+
+// validateShortCode returns why a user-chosen code can't be used, or an empty string.
+func validateShortCode(code string) string {
+	if !utils.IsValidShortCode(code) {
+		return "must have 1 to 64 letters, digits, '-' or '_' starting and ending with a letter or digit"
+	}
+
+	if isReservedWord(code) {
+		return "is reserved"
+	}
+
+	return ""
+}
+
+func isReservedWord(code string) bool {
+	return containsFold(builtinReservedWords, code) || containsFold(config.ApplicationConfig.Redirect.ShortCode.ReservedWords, code)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/port/repository"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"math/rand/v2"
+	"strings"
+)
+
+const (
+	ASSIGNMENT_SEPARATOR   = "."
+	ASSIGNMENT_SECRET_SIZE = 32
+)
+
+// SplitService runs the A/B tests of split redirects. Visitors are assigned a variant
+// by weight and keep it through a signed assignment, so they can't pick a variant
+// themselves and an assignment of one redirect is worthless for another.
+type SplitService struct {
+	repository repository.IVariantRepository
+	secret     []byte
+}
+
+func NewSplitService(repository repository.IVariantRepository) *SplitService {
+	secret := []byte(config.ApplicationConfig.Split.CookieSecret)
+	if len(secret) == constants.ZERO {
+		log.Warn(context.Background()).Msg("No split cookie secret configured, visitors will be reassigned on restart")
+
+		secret = make([]byte, ASSIGNMENT_SECRET_SIZE)
+		if _, err := cryptorand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+
+	return &SplitService{
+		repository: repository,
+		secret:     secret,
+	}
+}
+
+// Assign returns the variant of a visitor: the one of its assignment while it is still
+// served, or else a new one drawn by weight. The assignment to keep is returned along.
+func (service *SplitService) Assign(redirect entity.Redirect, assignment string) (entity.Variant, string) {
+	if name, ok := service.verify(redirect, assignment); ok {
+		if variant, found := findVariant(redirect, name); found && variant.Weight > constants.ZERO {
+			return variant, assignment
+		}
+	}
+
+	variant := drawVariant(redirect.Variants)
+	return variant, service.sign(redirect, variant.Name)
+}
+
+func (service *SplitService) RecordHit(ctx context.Context, redirect entity.Redirect, variant entity.Variant) *exceptions.WrappedError {
+	return service.repository.IncrementHits(ctx, redirect.ID, variant.Name)
+}
+
+// RecordConversion counts a conversion for the variant of a signed assignment. A bare
+// variant name isn't accepted, so conversions can't be counted for a variant the
+// visitor wasn't served.
+func (service *SplitService) RecordConversion(ctx context.Context, redirect entity.Redirect, assignment string) *exceptions.WrappedError {
+	variantName, ok := service.verify(redirect, assignment)
+	if !ok {
+		return &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "Missing or invalid variant assignment for redirect [" + redirect.ID + "]",
+		}
+	}
+
+	if _, found := findVariant(redirect, variantName); !found {
+		return &exceptions.WrappedError{
+			BaseError: exceptions.InvalidParameter,
+			Message:   "Unknown variant [" + variantName + "] for redirect [" + redirect.ID + "]",
+		}
+	}
+
+	return service.repository.IncrementConversions(ctx, redirect.ID, variantName)
+}
+
+// GetStats compares the variants of a redirect, including those without hits yet.
+// Counters of variants since removed from the redirect are reported with weight 0.
+func (service *SplitService) GetStats(ctx context.Context, redirect entity.Redirect) ([]entity.VariantStats, *exceptions.WrappedError) {
+	counters, errw := service.repository.GetAll(ctx, redirect.ID)
+	if errw != nil {
+		return []entity.VariantStats{}, errw
+	}
+
+	countersByVariant := make(map[string]entity.VariantCounter)
+	for _, counter := range counters {
+		countersByVariant[counter.Variant] = counter
+	}
+
+	stats := []entity.VariantStats{}
+	for _, variant := range redirect.Variants {
+		counter, found := countersByVariant[variant.Name]
+		if !found {
+			counter = entity.VariantCounter{RedirectID: redirect.ID, Variant: variant.Name}
+		}
+		delete(countersByVariant, variant.Name)
+
+		stats = append(stats, newVariantStats(counter, variant.Weight))
+	}
+
+	for _, counter := range counters {
+		if _, removed := countersByVariant[counter.Variant]; removed {
+			stats = append(stats, newVariantStats(counter, constants.ZERO))
+		}
+	}
+
+	return stats, nil
+}
+
+func newVariantStats(counter entity.VariantCounter, weight int) entity.VariantStats {
+	stats := entity.VariantStats{
+		VariantCounter: counter,
+		Weight:         weight,
+	}
+
+	if counter.Hits > constants.ZERO {
+		stats.ConversionRate = float64(counter.Conversions) / float64(counter.Hits)
+	}
+
+	return stats
+}
+
+func (service *SplitService) sign(redirect entity.Redirect, variantName string) string {
+	mac := hmac.New(sha256.New, service.secret)
+	mac.Write([]byte(redirect.ID + ASSIGNMENT_SEPARATOR + variantName))
+
+	return variantName + ASSIGNMENT_SEPARATOR + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (service *SplitService) verify(redirect entity.Redirect, assignment string) (string, bool) {
+	if utils.IsEmptyStr(assignment) {
+		return "", false
+	}
+
+	index := strings.LastIndex(assignment, ASSIGNMENT_SEPARATOR)
+	if index < constants.ZERO {
+		return "", false
+	}
+
+	variantName := assignment[:index]
+	if !hmac.Equal([]byte(service.sign(redirect, variantName)), []byte(assignment)) {
+		return "", false
+	}
+
+	return variantName, true
+}
+
+func findVariant(redirect entity.Redirect, name string) (entity.Variant, bool) {
+	for _, variant := range redirect.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+
+	return entity.Variant{}, false
+}
+
+func drawVariant(variants []entity.Variant) entity.Variant {
+	total := 0
+	for _, variant := range variants {
+		total += max(variant.Weight, constants.ZERO)
+	}
+
+	if total == constants.ZERO {
+		return variants[0]
+	}
+
+	pick := rand.IntN(total)
+	for _, variant := range variants {
+		pick -= max(variant.Weight, constants.ZERO)
+		if pick < constants.ZERO {
+			return variant
+		}
+	}
+
+	return variants[len(variants)-1]
+}
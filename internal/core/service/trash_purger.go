@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"strconv"
+	"time"
+)
+
+const TRASH_PRINCIPAL = "system:trash"
+
+// StartTrashPurger periodically purges redirects that have been in the trash longer
+// than the configured retention. Without a retention the trash is kept forever.
+func (service *RedirectService) StartTrashPurger(ctx context.Context) {
+	trashConfig := config.ApplicationConfig.Redirect.Trash
+	if trashConfig.Retention <= constants.ZERO {
+		return
+	}
+
+	interval := trashConfig.PurgeInterval
+	if interval <= constants.ZERO {
+		interval = DEFAULT_PURGE_INTERVAL
+	}
+
+	// purges are recorded in the history of the redirects as made by the purger
+	ctx = context.WithValue(ctx, constants.PRINCIPAL, TRASH_PRINCIPAL)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				service.purgeTrash(ctx)
+			}
+		}
+	}()
+}
+
+func (service *RedirectService) purgeTrash(ctx context.Context) {
+	before := time.Now().Add(-config.ApplicationConfig.Redirect.Trash.Retention)
+	purged := 0
+
+	for {
+		redirects, errw := service.repository.GetPurgeable(ctx, before, PURGE_BATCH_SIZE)
+		if errw != nil {
+			log.Error(ctx).Msg("Error searching trashed redirects: " + errw.GetMessage())
+			break
+		}
+
+		for _, redirect := range redirects {
+			if errw := service.repository.Purge(ctx, redirect); errw != nil {
+				log.Error(ctx).Msg("Error purging redirect " + redirect.ID + ": " + errw.GetMessage())
+				return
+			}
+			purged++
+		}
+
+		if len(redirects) < PURGE_BATCH_SIZE {
+			break
+		}
+	}
+
+	if purged > constants.ZERO {
+		log.Info(ctx).Msg("Purged " + strconv.Itoa(purged) + " redirects from the trash")
+	}
+}
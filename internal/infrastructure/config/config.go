@@ -7,6 +7,7 @@ import (
 	"fernandoglatz/url-management/internal/core/common/utils/log"
 	"fernandoglatz/url-management/internal/infrastructure/config/format"
 	"os"
+	"regexp"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -16,6 +17,26 @@ type Config struct {
 	Server struct {
 		Listening   string `yaml:"listening"`
 		ContextPath string `yaml:"context-path"`
+
+		ReadHeaderTimeout time.Duration `yaml:"read-header-timeout"`
+		ReadTimeout       time.Duration `yaml:"read-timeout"`
+		WriteTimeout      time.Duration `yaml:"write-timeout"`
+		IdleTimeout       time.Duration `yaml:"idle-timeout"`
+		ShutdownTimeout   time.Duration `yaml:"shutdown-timeout"`
+
+		TLS struct {
+			Enabled      bool   `yaml:"enabled"`
+			Listening    string `yaml:"listening"`
+			RedirectHTTP bool   `yaml:"redirect-http"`
+			PublicPort   string `yaml:"public-port"`
+
+			ACME struct {
+				DirectoryURL string        `yaml:"directory-url"`
+				Email        string        `yaml:"email"`
+				CAFile       string        `yaml:"ca-file"`
+				RenewBefore  time.Duration `yaml:"renew-before"`
+			} `yaml:"acme"`
+		} `yaml:"tls"`
 	} `yaml:"server"`
 
 	Data struct {
@@ -31,10 +52,117 @@ type Config struct {
 
 			TTL struct {
 				Redirect time.Duration `yaml:"redirect"`
+				Negative time.Duration `yaml:"negative"`
 			} `yaml:"ttl"`
 		} `yaml:"redis"`
+
+		GeoIP struct {
+			Database string `yaml:"database"`
+		} `yaml:"geoip"`
 	} `yaml:"data"`
 
+	Analytics struct {
+		Enabled       bool          `yaml:"enabled"`
+		BufferSize    int           `yaml:"buffer-size"`
+		BatchSize     int           `yaml:"batch-size"`
+		FlushInterval time.Duration `yaml:"flush-interval"`
+		IPSecret      string        `yaml:"ip-secret"`
+		TopReferers   int           `yaml:"top-referers"`
+	} `yaml:"analytics"`
+
+	Redirect struct {
+		ShortCode struct {
+			Length        int      `yaml:"length"`
+			MaxAttempts   int      `yaml:"max-attempts"`
+			ReservedWords []string `yaml:"reserved-words"`
+		} `yaml:"short-code"`
+
+		Validation struct {
+			AllowedSchemes []string `yaml:"allowed-schemes"`
+		} `yaml:"validation"`
+
+		Expiration struct {
+			Fallback struct {
+				Type string `yaml:"type"`
+				URL  string `yaml:"url"`
+				Page string `yaml:"page"`
+			} `yaml:"fallback"`
+
+			Purge         bool          `yaml:"purge"`
+			PurgeAfter    time.Duration `yaml:"purge-after"`
+			PurgeInterval time.Duration `yaml:"purge-interval"`
+		} `yaml:"expiration"`
+
+		Trash struct {
+			Retention     time.Duration `yaml:"retention"`
+			PurgeInterval time.Duration `yaml:"purge-interval"`
+		} `yaml:"trash"`
+	} `yaml:"redirect"`
+
+	Proxy struct {
+		MaxIdleConns          int           `yaml:"max-idle-conns"`
+		MaxIdleConnsPerHost   int           `yaml:"max-idle-conns-per-host"`
+		IdleConnTimeout       time.Duration `yaml:"idle-conn-timeout"`
+		DialTimeout           time.Duration `yaml:"dial-timeout"`
+		TLSHandshakeTimeout   time.Duration `yaml:"tls-handshake-timeout"`
+		ResponseHeaderTimeout time.Duration `yaml:"response-header-timeout"`
+		MaxRewriteSize        int64         `yaml:"max-rewrite-size"`
+		Rewriter              string        `yaml:"rewriter"`
+	} `yaml:"proxy"`
+
+	Split struct {
+		CookieSecret    string        `yaml:"cookie-secret"`
+		CookieMaxAge    time.Duration `yaml:"cookie-max-age"`
+		AssignmentParam string        `yaml:"assignment-param"`
+	} `yaml:"split"`
+
+	Balancing struct {
+		Cookie string `yaml:"cookie"`
+
+		HealthCheck struct {
+			Interval           time.Duration `yaml:"interval"`
+			Timeout            time.Duration `yaml:"timeout"`
+			UnhealthyThreshold int           `yaml:"unhealthy-threshold"`
+			HealthyThreshold   int           `yaml:"healthy-threshold"`
+		} `yaml:"health-check"`
+
+		Ejection struct {
+			ConsecutiveFailures int           `yaml:"consecutive-failures"`
+			Duration            time.Duration `yaml:"duration"`
+		} `yaml:"ejection"`
+	} `yaml:"balancing"`
+
+	Health struct {
+		Required []string      `yaml:"required"`
+		Timeout  time.Duration `yaml:"timeout"`
+	} `yaml:"health"`
+
+	Metrics struct {
+		Enabled    bool `yaml:"enabled"`
+		LabelLimit int  `yaml:"label-limit"`
+	} `yaml:"metrics"`
+
+	Tracing struct {
+		Enabled     bool    `yaml:"enabled"`
+		ServiceName string  `yaml:"service-name"`
+		SampleRatio float64 `yaml:"sample-ratio"`
+
+		OTLP struct {
+			Endpoint string            `yaml:"endpoint"`
+			Insecure bool              `yaml:"insecure"`
+			Headers  map[string]string `yaml:"headers"`
+		} `yaml:"otlp"`
+	} `yaml:"tracing"`
+
+	Security struct {
+		Enabled bool `yaml:"enabled"`
+
+		BasicAuth struct {
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+		} `yaml:"basic-auth"`
+	} `yaml:"security"`
+
 	Log struct {
 		Level   string        `yaml:"level"`
 		Format  format.Format `yaml:"format"`
@@ -51,6 +179,8 @@ type Tenant struct {
 
 var ApplicationConfig Config
 
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 func LoadConfig(ctx context.Context) error {
 	loadProfile(ctx)
 
@@ -89,7 +219,9 @@ func loadLocalConfig(ctx context.Context) error {
 		return errors.New("Failed to read configuration file: " + err.Error())
 	}
 
-	err = yaml.Unmarshal(data, &ApplicationConfig)
+	content := expandEnv(string(data))
+
+	err = yaml.Unmarshal([]byte(content), &ApplicationConfig)
 	if err != nil {
 		return errors.New("Failed to parse configuration file: " + err.Error())
 	}
@@ -98,3 +230,13 @@ func loadLocalConfig(ctx context.Context) error {
 
 	return nil
 }
+
+// expandEnv replaces the ${VAR} references with the environment, leaving any other $
+// as is so that values such as passwords can hold one. An unset variable expands to
+// nothing, which leaves the secrets it would have set generated or disabled rather
+// than set to the reference itself.
+func expandEnv(content string) string {
+	return envReference.ReplaceAllStringFunc(content, func(reference string) string {
+		return os.Getenv(envReference.FindStringSubmatch(reference)[constants.ONE])
+	})
+}
@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	NAMESPACE = "url_management"
+
+	DEFAULT_LABEL_LIMIT = 1000
+	OTHER_LABEL         = "other"
+	NO_ROUTE_LABEL      = "noroute"
+
+	CACHE_HIT   = "hit"
+	CACHE_MISS  = "miss"
+	CACHE_ERROR = "error"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests served, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	redirectHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "redirect_hits_total",
+		Help:      "Hits per redirect. Redirects beyond the label limit are counted as \"" + OTHER_LABEL + "\".",
+	}, []string{"redirect"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "proxy_upstream_duration_seconds",
+		Help:      "Latency of the requests proxied upstream, by upstream host.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"upstream"})
+
+	upstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "proxy_upstream_errors_total",
+		Help:      "Proxied requests that failed or got a 5xx answer, by upstream host.",
+	}, []string{"upstream"})
+
+	rewrittenBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "proxy_rewritten_bytes_total",
+		Help:      "Bytes of upstream bodies passed through the rewriter.",
+	})
+
+	webSocketTunnels = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Name:      "websocket_tunnels_open",
+		Help:      "WebSocket tunnels currently open.",
+	})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "cache_requests_total",
+		Help:      "Redis cache lookups, by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+
+	mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Latency of the Mongo operations, by collection and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "operation"})
+)
+
+var (
+	redirectLabels = &boundedLabel{values: make(map[string]bool)}
+	upstreamLabels = &boundedLabel{values: make(map[string]bool)}
+)
+
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// boundedLabel admits label values until the configured limit of distinct ones is
+// reached, then folds every new one into OTHER_LABEL, so that label values coming from
+// data (redirect ids, upstream hosts) can't grow a metric without bound.
+type boundedLabel struct {
+	mutex  sync.RWMutex
+	values map[string]bool
+}
+
+func (label *boundedLabel) value(value string) string {
+	label.mutex.RLock()
+	known := label.values[value]
+	label.mutex.RUnlock()
+	if known {
+		return value
+	}
+
+	label.mutex.Lock()
+	defer label.mutex.Unlock()
+
+	if label.values[value] {
+		return value
+	}
+	if len(label.values) >= labelLimit() {
+		return OTHER_LABEL
+	}
+
+	label.values[value] = true
+	return value
+}
+
+func labelLimit() int {
+	limit := config.ApplicationConfig.Metrics.LabelLimit
+	if limit <= constants.ZERO {
+		return DEFAULT_LABEL_LIMIT
+	}
+	return limit
+}
+
+func ObserveRequest(method string, route string, status int, elapsed time.Duration) {
+	if !knownMethods[method] {
+		method = OTHER_LABEL
+	}
+	if route == "" {
+		route = NO_ROUTE_LABEL
+	}
+
+	statusLabel := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+	httpRequestDuration.WithLabelValues(method, route, statusLabel).Observe(elapsed.Seconds())
+}
+
+func RecordRedirectHit(redirectId string) {
+	redirectHits.WithLabelValues(redirectLabels.value(redirectId)).Inc()
+}
+
+func ObserveUpstream(host string, elapsed time.Duration, failed bool) {
+	upstream := upstreamLabels.value(host)
+	upstreamDuration.WithLabelValues(upstream).Observe(elapsed.Seconds())
+	if failed {
+		upstreamErrors.WithLabelValues(upstream).Inc()
+	}
+}
+
+func RecordUpstreamError(host string) {
+	upstreamErrors.WithLabelValues(upstreamLabels.value(host)).Inc()
+}
+
+func AddRewrittenBytes(count int) {
+	rewrittenBytes.Add(float64(count))
+}
+
+func TunnelOpened() {
+	webSocketTunnels.Inc()
+}
+
+func TunnelClosed() {
+	webSocketTunnels.Dec()
+}
+
+func RecordCache(cache string, result string) {
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveMongo records the time elapsed since begin, and is meant to be deferred at
+// the start of the operation: defer metrics.ObserveMongo(collection, operation, time.Now()).
+func ObserveMongo(collection string, operation string, begin time.Time) {
+	mongoDuration.WithLabelValues(collection, operation).Observe(time.Since(begin).Seconds())
+}
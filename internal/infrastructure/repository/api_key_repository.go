@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ApiKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewApiKeyRepository() *ApiKeyRepository {
+	return &ApiKeyRepository{
+		collection: utils.MongoDatabase.GetCollection("api_key"),
+	}
+}
+
+func (repository *ApiKeyRepository) Get(ctx context.Context, id string) (entity.ApiKey, *exceptions.WrappedError) {
+	filter := bson.M{"id": id}
+	return repository.getByFilter(ctx, filter)
+}
+
+func (repository *ApiKeyRepository) GetByHash(ctx context.Context, hash string) (entity.ApiKey, *exceptions.WrappedError) {
+	filter := bson.M{"hash": hash}
+	return repository.getByFilter(ctx, filter)
+}
+
+func (repository *ApiKeyRepository) getByFilter(ctx context.Context, filter interface{}) (entity.ApiKey, *exceptions.WrappedError) {
+	var apiKey entity.ApiKey
+
+	err := repository.collection.FindOne(ctx, filter).Decode(&apiKey)
+	if err == mongo.ErrNoDocuments {
+		return apiKey, &exceptions.WrappedError{
+			BaseError: exceptions.RecordNotFound,
+		}
+	} else if err != nil {
+		return apiKey, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	repository.correctTimezone(&apiKey)
+	return apiKey, nil
+}
+
+func (repository *ApiKeyRepository) GetAll(ctx context.Context) ([]entity.ApiKey, *exceptions.WrappedError) {
+	var apiKeys []entity.ApiKey = []entity.ApiKey{}
+
+	cursor, err := repository.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return apiKeys, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var apiKey entity.ApiKey
+		err = cursor.Decode(&apiKey)
+		if err != nil {
+			return apiKeys, &exceptions.WrappedError{
+				Error: err,
+			}
+		}
+
+		repository.correctTimezone(&apiKey)
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, nil
+}
+
+func (repository *ApiKeyRepository) Save(ctx context.Context, apiKey *entity.ApiKey) *exceptions.WrappedError {
+	now := time.Now()
+	apiKey.UpdatedAt = now
+
+	if len(apiKey.ID) == constants.ZERO {
+		uuidObj, _ := uuid.NewRandom()
+		uuidStr := uuidObj.String()
+		apiKey.ID = strings.Replace(uuidStr, "-", "", -1)
+	}
+
+	if apiKey.CreatedAt.IsZero() {
+		apiKey.CreatedAt = now
+
+		_, err := repository.collection.InsertOne(ctx, apiKey)
+		if err != nil {
+			return &exceptions.WrappedError{
+				Error: err,
+			}
+		}
+
+	} else {
+		filter := bson.M{"id": apiKey.ID}
+		_, err := repository.collection.ReplaceOne(ctx, filter, apiKey)
+		if err != nil {
+			return &exceptions.WrappedError{
+				Error: err,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (repository *ApiKeyRepository) correctTimezone(apiKey *entity.ApiKey) {
+	location, _ := time.LoadLocation(utils.GetTimezone())
+	apiKey.CreatedAt = apiKey.CreatedAt.In(location)
+	apiKey.UpdatedAt = apiKey.UpdatedAt.In(location)
+
+	if apiKey.RevokedAt != nil {
+		revokedAt := apiKey.RevokedAt.In(location)
+		apiKey.RevokedAt = &revokedAt
+	}
+}
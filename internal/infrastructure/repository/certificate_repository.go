@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CertificateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCertificateRepository() *CertificateRepository {
+	return &CertificateRepository{
+		collection: utils.MongoDatabase.GetCollection("certificate"),
+	}
+}
+
+func (repository *CertificateRepository) Get(ctx context.Context, key string) (entity.Certificate, *exceptions.WrappedError) {
+	var certificate entity.Certificate
+
+	err := repository.collection.FindOne(ctx, bson.M{"key": key}).Decode(&certificate)
+	if err == mongo.ErrNoDocuments {
+		return certificate, &exceptions.WrappedError{
+			BaseError: exceptions.RecordNotFound,
+		}
+	} else if err != nil {
+		return certificate, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return certificate, nil
+}
+
+func (repository *CertificateRepository) Save(ctx context.Context, certificate *entity.Certificate) *exceptions.WrappedError {
+	certificate.UpdatedAt = time.Now()
+
+	filter := bson.M{"key": certificate.Key}
+	_, err := repository.collection.ReplaceOne(ctx, filter, certificate, options.Replace().SetUpsert(true))
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
+
+func (repository *CertificateRepository) Remove(ctx context.Context, key string) *exceptions.WrappedError {
+	_, err := repository.collection.DeleteOne(ctx, bson.M{"key": key})
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ClickRepository struct {
+	collection      *mongo.Collection
+	dailyCollection *mongo.Collection
+}
+
+func NewClickRepository() *ClickRepository {
+	return &ClickRepository{
+		collection:      utils.MongoDatabase.GetCollection("click"),
+		dailyCollection: utils.MongoDatabase.GetCollection("click_daily"),
+	}
+}
+
+// SaveAll stores the raw clicks and rolls them up into the per-day counters.
+func (repository *ClickRepository) SaveAll(ctx context.Context, clicks []entity.Click) *exceptions.WrappedError {
+	if len(clicks) == constants.ZERO {
+		return nil
+	}
+
+	type counterKey struct {
+		redirectID string
+		day        int64
+	}
+
+	documents := make([]interface{}, 0, len(clicks))
+	counters := make(map[counterKey]int64)
+
+	for _, click := range clicks {
+		documents = append(documents, click)
+
+		day := utils.StartOfDay(click.Timestamp)
+		counters[counterKey{redirectID: click.RedirectID, day: day.Unix()}]++
+	}
+
+	_, err := repository.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	models := make([]mongo.WriteModel, 0, len(counters))
+	for key, count := range counters {
+		filter := bson.M{"redirectId": key.redirectID, "day": time.Unix(key.day, 0)}
+		update := bson.M{"$inc": bson.M{"count": count}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+
+	_, err = repository.dailyCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
+
+func (repository *ClickRepository) GetDailyCounters(ctx context.Context, redirectID string, from time.Time, to time.Time) ([]entity.ClickCounter, *exceptions.WrappedError) {
+	var counters []entity.ClickCounter = []entity.ClickCounter{}
+
+	filter := bson.M{
+		"redirectId": redirectID,
+		"day":        bson.M{"$gte": from, "$lte": to},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "day", Value: 1}})
+
+	cursor, err := repository.dailyCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return counters, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var counter entity.ClickCounter
+		err = cursor.Decode(&counter)
+		if err != nil {
+			return counters, &exceptions.WrappedError{
+				Error: err,
+			}
+		}
+
+		counters = append(counters, counter)
+	}
+
+	return counters, nil
+}
+
+func (repository *ClickRepository) GetTopReferers(ctx context.Context, redirectID string, from time.Time, to time.Time, limit int) ([]entity.RefererCounter, *exceptions.WrappedError) {
+	var referers []entity.RefererCounter = []entity.RefererCounter{}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"redirectId": redirectID,
+			"timestamp":  bson.M{"$gte": from, "$lt": to},
+			"referer":    bson.M{"$exists": true},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$referer", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := repository.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return referers, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &referers)
+	if err != nil {
+		return referers, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return referers, nil
+}
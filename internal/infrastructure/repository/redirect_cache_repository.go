@@ -3,16 +3,33 @@ package repository
 import (
 	"context"
 	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
 	"fernandoglatz/url-management/internal/core/common/utils/log"
 	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
 	"fernandoglatz/url-management/internal/core/port/repository"
 	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/metrics"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
+	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const REDIRECT_CACHE_KEY_PREFIX = "url-management:redirect:"
+const (
+	REDIRECT_CACHE_KEY_PREFIX             = "url-management:redirect:"
+	REDIRECT_DNS_CACHE_KEY_PREFIX         = REDIRECT_CACHE_KEY_PREFIX + "dns:"
+	REDIRECT_DNS_GENERATION_CACHE_KEY     = REDIRECT_CACHE_KEY_PREFIX + "dns-generation"
+	REDIRECT_DNS_CACHE_KEY_SEPARATOR      = ","
+	REDIRECT_DNS_GENERATION_KEY_SEPARATOR = ":"
+
+	REDIRECT_CACHE     = "redirect"
+	REDIRECT_DNS_CACHE = "dns"
+)
 
 type RedirectCacheRepository struct {
 	repository repository.IRedirectRepository
@@ -25,9 +42,13 @@ func NewRedirectCacheRepository(repository repository.IRedirectRepository) *Redi
 }
 
 func (cacheRepository *RedirectCacheRepository) Get(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	ctx, span := startLookup(ctx, REDIRECT_CACHE)
+	defer span.End()
+
 	cacheKey := REDIRECT_CACHE_KEY_PREFIX + id
 	var redirect entity.Redirect
 	cacheErr := utils.RedisDatabase.GetStruct(ctx, cacheKey, &redirect)
+	recordLookup(span, REDIRECT_CACHE, cacheErr)
 	if cacheErr == nil {
 		return redirect, nil
 	}
@@ -38,53 +59,112 @@ func (cacheRepository *RedirectCacheRepository) Get(ctx context.Context, id stri
 	if errw != nil {
 		return redirect, errw
 	}
-	ttl := config.ApplicationConfig.Data.Redis.TTL.Redirect
+	ttl := cacheTTL(config.ApplicationConfig.Data.Redis.TTL.Redirect, redirect)
 	if err := utils.RedisDatabase.SetStruct(ctx, cacheKey, redirect, ttl); err != nil {
 		log.Error(ctx).Msg("Error adding redirect to cache: " + err.Error())
 	}
 	return redirect, nil
 }
 
-func (cacheRepository *RedirectCacheRepository) GetByDNS(ctx context.Context, dns string) (entity.Redirect, *exceptions.WrappedError) {
-	cacheKey := REDIRECT_CACHE_KEY_PREFIX + dns
-	var redirect entity.Redirect
-	cacheErr := utils.RedisDatabase.GetStruct(ctx, cacheKey, &redirect)
+// GetAllByDNS caches the whole resolution of a host (its exact and wildcard entries
+// with every path rule) under a single key, including empty results so unknown
+// hosts don't reach Mongo on every request. A wildcard entry can serve any number
+// of hosts, so instead of tracking them the keys embed a generation number that
+// is bumped whenever a DNS entry changes.
+func (cacheRepository *RedirectCacheRepository) GetAllByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	ctx, span := startLookup(ctx, REDIRECT_DNS_CACHE)
+	defer span.End()
+
+	generation, err := utils.RedisDatabase.Get(ctx, REDIRECT_DNS_GENERATION_CACHE_KEY).Result()
+	if err == redis.Nil {
+		generation = "0"
+	} else if err != nil {
+		log.Error(ctx).Msg("Error retrieving DNS generation from cache: " + err.Error())
+		recordLookup(span, REDIRECT_DNS_CACHE, err)
+		return cacheRepository.repository.GetAllByDNS(ctx, dnsList)
+	}
+
+	cacheKey := REDIRECT_DNS_CACHE_KEY_PREFIX + generation + REDIRECT_DNS_GENERATION_KEY_SEPARATOR + strings.Join(dnsList, REDIRECT_DNS_CACHE_KEY_SEPARATOR)
+	var redirects []entity.Redirect
+	cacheErr := utils.RedisDatabase.GetStruct(ctx, cacheKey, &redirects)
+	recordLookup(span, REDIRECT_DNS_CACHE, cacheErr)
 	if cacheErr == nil {
-		return redirect, nil
+		return redirects, nil
 	}
 	if cacheErr != redis.Nil {
-		log.Error(ctx).Msg("Error retrieving redirect by DNS from cache: " + cacheErr.Error())
+		log.Error(ctx).Msg("Error retrieving redirects by DNS from cache: " + cacheErr.Error())
 	}
-	redirect, errw := cacheRepository.repository.GetByDNS(ctx, dns)
+	redirects, errw := cacheRepository.repository.GetAllByDNS(ctx, dnsList)
 	if errw != nil {
-		return redirect, errw
+		return redirects, errw
 	}
-	ttl := config.ApplicationConfig.Data.Redis.TTL.Redirect
-	if err := utils.RedisDatabase.SetStruct(ctx, cacheKey, redirect, ttl); err != nil {
-		log.Error(ctx).Msg("Error adding redirect by DNS to cache: " + err.Error())
+	ttlConfig := config.ApplicationConfig.Data.Redis.TTL
+	ttl := cacheTTL(ttlConfig.Redirect, redirects...)
+	if len(redirects) == constants.ZERO && ttlConfig.Negative > constants.ZERO {
+		ttl = ttlConfig.Negative
 	}
-	return redirect, nil
+	if err := utils.RedisDatabase.SetStruct(ctx, cacheKey, redirects, ttl); err != nil {
+		log.Error(ctx).Msg("Error adding redirects by DNS to cache: " + err.Error())
+	}
+	return redirects, nil
 }
 
 func (cacheRepository *RedirectCacheRepository) GetAll(ctx context.Context) ([]entity.Redirect, *exceptions.WrappedError) {
 	return cacheRepository.repository.GetAll(ctx)
 }
 
+func (cacheRepository *RedirectCacheRepository) GetExpired(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError) {
+	return cacheRepository.repository.GetExpired(ctx, before, limit)
+}
+
+func (cacheRepository *RedirectCacheRepository) IncrementClicks(ctx context.Context, id string) (int64, *exceptions.WrappedError) {
+	return cacheRepository.repository.IncrementClicks(ctx, id)
+}
+
+func (cacheRepository *RedirectCacheRepository) Find(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	return cacheRepository.repository.Find(ctx, filter)
+}
+
+func (cacheRepository *RedirectCacheRepository) GetTrashed(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	return cacheRepository.repository.GetTrashed(ctx, id)
+}
+
+func (cacheRepository *RedirectCacheRepository) GetTrashedByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	return cacheRepository.repository.GetTrashedByDNS(ctx, dnsList)
+}
+
+func (cacheRepository *RedirectCacheRepository) FindTrash(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	return cacheRepository.repository.FindTrash(ctx, filter)
+}
+
+func (cacheRepository *RedirectCacheRepository) GetPurgeable(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError) {
+	return cacheRepository.repository.GetPurgeable(ctx, before, limit)
+}
+
 func (cacheRepository *RedirectCacheRepository) Save(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
 	errw := cacheRepository.repository.Save(ctx, redirect)
 	if errw != nil {
 		return errw
 	}
 
-	cacheKey := REDIRECT_CACHE_KEY_PREFIX + redirect.ID
-	if err := utils.RedisDatabase.Del(ctx, cacheKey); err != nil {
-		log.Error(ctx).Msg("Error removing redirect from cache: " + err.Error())
+	cacheRepository.evict(ctx, *redirect)
+	return nil
+}
+
+// SaveMany evicts every redirect of the batch, and bumps the DNS generation once for
+// all of them.
+func (cacheRepository *RedirectCacheRepository) SaveMany(ctx context.Context, redirects []*entity.Redirect) *exceptions.WrappedError {
+	errw := cacheRepository.repository.SaveMany(ctx, redirects)
+	if errw != nil {
+		return errw
 	}
 
-	dnsCacheKey := REDIRECT_CACHE_KEY_PREFIX + redirect.DNS
-	if err := utils.RedisDatabase.Del(ctx, dnsCacheKey); err != nil {
-		log.Error(ctx).Msg("Error removing DNS entry from cache: " + err.Error())
+	cacheKeys := make([]string, constants.ZERO, len(redirects))
+	for _, redirect := range redirects {
+		cacheKeys = append(cacheKeys, REDIRECT_CACHE_KEY_PREFIX+redirect.ID)
 	}
+
+	cacheRepository.evictKeys(ctx, cacheKeys...)
 	return nil
 }
 
@@ -94,15 +174,82 @@ func (cacheRepository *RedirectCacheRepository) Remove(ctx context.Context, redi
 		return errw
 	}
 
-	cacheKey := REDIRECT_CACHE_KEY_PREFIX + redirect.ID
-	if err := utils.RedisDatabase.Del(ctx, cacheKey); err != nil {
-		log.Error(ctx).Msg("Error removing redirect from cache: " + err.Error())
+	cacheRepository.evict(ctx, redirect)
+	return nil
+}
+
+func (cacheRepository *RedirectCacheRepository) Restore(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	errw := cacheRepository.repository.Restore(ctx, redirect)
+	if errw != nil {
+		return errw
 	}
 
-	dnsCacheKey := REDIRECT_CACHE_KEY_PREFIX + redirect.DNS
-	if err := utils.RedisDatabase.Del(ctx, dnsCacheKey); err != nil {
-		log.Error(ctx).Msg("Error removing DNS entry from cache: " + err.Error())
+	cacheRepository.evict(ctx, *redirect)
+	return nil
+}
+
+func (cacheRepository *RedirectCacheRepository) Purge(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError {
+	errw := cacheRepository.repository.Purge(ctx, redirect)
+	if errw != nil {
+		return errw
 	}
 
+	cacheRepository.evict(ctx, redirect)
 	return nil
 }
+
+func (cacheRepository *RedirectCacheRepository) evict(ctx context.Context, redirect entity.Redirect) {
+	cacheRepository.evictKeys(ctx, REDIRECT_CACHE_KEY_PREFIX+redirect.ID)
+}
+
+func (cacheRepository *RedirectCacheRepository) evictKeys(ctx context.Context, cacheKeys ...string) {
+	if err := utils.RedisDatabase.Del(ctx, cacheKeys...); err != nil {
+		log.Error(ctx).Msg("Error removing redirect from cache: " + err.Error())
+	}
+
+	if err := utils.RedisDatabase.Client.Incr(ctx, REDIRECT_DNS_GENERATION_CACHE_KEY).Err(); err != nil {
+		log.Error(ctx).Msg("Error invalidating DNS entries from cache: " + err.Error())
+	}
+}
+
+// startLookup starts the span of a cache lookup, which also covers the Mongo query
+// made on a miss.
+func startLookup(ctx context.Context, cache string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "cache "+cache, trace.WithAttributes(attribute.String("cache.name", cache)))
+}
+
+// recordLookup counts the result of a cache lookup and sets it on the lookup's span.
+func recordLookup(span trace.Span, cache string, err error) {
+	result := metrics.CACHE_ERROR
+	switch err {
+	case nil:
+		result = metrics.CACHE_HIT
+	case redis.Nil:
+		result = metrics.CACHE_MISS
+	default:
+		span.RecordError(err)
+	}
+
+	metrics.RecordCache(cache, result)
+	span.SetAttributes(attribute.String("cache.result", result))
+}
+
+// cacheTTL shortens the configured TTL so that a cached redirect never outlives its
+// next activation or expiration.
+func cacheTTL(ttl time.Duration, redirects ...entity.Redirect) time.Duration {
+	now := time.Now()
+
+	for _, redirect := range redirects {
+		transition := redirect.NextTransition(now)
+		if transition == nil {
+			continue
+		}
+
+		untilTransition := transition.Sub(now)
+		if ttl <= constants.ZERO || untilTransition < ttl {
+			ttl = untilTransition
+		}
+	}
+
+	return ttl
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/port/repository"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeRedirectRepository answers the lookups the cache falls back to on a miss.
+type fakeRedirectRepository struct {
+	repository.IRedirectRepository
+	redirect entity.Redirect
+}
+
+func (fake *fakeRedirectRepository) Get(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	return fake.redirect, nil
+}
+
+func (fake *fakeRedirectRepository) GetAllByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	return []entity.Redirect{fake.redirect}, nil
+}
+
+func TestRedirectCacheRepositoryTracesLookups(t *testing.T) {
+	ctx := context.Background()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := tracing.Install(ctx, sdktrace.WithSyncer(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Shutdown(ctx)
+
+	redisServer := miniredis.RunT(t)
+	config.ApplicationConfig.Data.Redis.Address = redisServer.Addr()
+	utils.ConnectToRedis(ctx)
+	defer utils.RedisDatabase.Client.Close()
+
+	cacheRepository := NewRedirectCacheRepository(&fakeRedirectRepository{
+		redirect: entity.Redirect{ID: "abc", DNS: "example.com"},
+	})
+
+	ctx, parent := tracing.Start(ctx, "parent")
+	cacheRepository.Get(ctx, "abc")
+	cacheRepository.Get(ctx, "abc")
+	cacheRepository.GetAllByDNS(ctx, []string{"example.com"})
+	cacheRepository.GetAllByDNS(ctx, []string{"example.com"})
+	redisServer.Close()
+	cacheRepository.Get(ctx, "abc")
+	cacheRepository.GetAllByDNS(ctx, []string{"example.com"})
+	parent.End()
+
+	expected := map[string][]string{
+		"cache redirect": {"miss", "hit", "error"},
+		"cache dns":      {"miss", "hit", "error"},
+	}
+	results := map[string][]string{}
+	for _, span := range exporter.GetSpans() {
+		if _, ok := expected[span.Name]; !ok {
+			continue
+		}
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected %s to be a child of the parent span", span.Name)
+		}
+
+		var cacheName, cacheResult string
+		for _, keyValue := range span.Attributes {
+			switch keyValue.Key {
+			case "cache.name":
+				cacheName = keyValue.Value.AsString()
+			case "cache.result":
+				cacheResult = keyValue.Value.AsString()
+			}
+		}
+		if "cache "+cacheName != span.Name {
+			t.Errorf("expected the cache name of %s, got %q", span.Name, cacheName)
+		}
+		results[span.Name] = append(results[span.Name], cacheResult)
+	}
+
+	for name, expectedResults := range expected {
+		if len(results[name]) != len(expectedResults) {
+			t.Errorf("expected %s results %v, got %v", name, expectedResults, results[name])
+			continue
+		}
+		for index := range expectedResults {
+			if results[name][index] != expectedResults[index] {
+				t.Errorf("expected %s results %v, got %v", name, expectedResults, results[name])
+				break
+			}
+		}
+	}
+}
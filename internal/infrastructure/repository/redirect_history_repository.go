@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
+	"fernandoglatz/url-management/internal/core/entity"
+	actiontype "fernandoglatz/url-management/internal/core/entity/action"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"fernandoglatz/url-management/internal/core/port/repository"
+	"time"
+)
+
+// errHistoryAborted aborts the transaction of a write failing without a Mongo error,
+// the write's own error being returned instead.
+var errHistoryAborted = errors.New("redirect write aborted")
+
+// RedirectHistoryRepository records a revision for every write to the redirects, with
+// the document before and after it and who made the change from where, as found in the
+// request context. It must wrap the Mongo repository directly, so the previous state
+// is never read from the cache.
+type RedirectHistoryRepository struct {
+	repository         repository.IRedirectRepository
+	revisionRepository repository.IRevisionRepository
+}
+
+func NewRedirectHistoryRepository(repository repository.IRedirectRepository, revisionRepository repository.IRevisionRepository) *RedirectHistoryRepository {
+	return &RedirectHistoryRepository{
+		repository:         repository,
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (historyRepository *RedirectHistoryRepository) Get(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.Get(ctx, id)
+}
+
+func (historyRepository *RedirectHistoryRepository) GetAllByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.GetAllByDNS(ctx, dnsList)
+}
+
+func (historyRepository *RedirectHistoryRepository) GetAll(ctx context.Context) ([]entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.GetAll(ctx)
+}
+
+func (historyRepository *RedirectHistoryRepository) Find(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	return historyRepository.repository.Find(ctx, filter)
+}
+
+func (historyRepository *RedirectHistoryRepository) GetTrashed(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.GetTrashed(ctx, id)
+}
+
+func (historyRepository *RedirectHistoryRepository) GetTrashedByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.GetTrashedByDNS(ctx, dnsList)
+}
+
+func (historyRepository *RedirectHistoryRepository) FindTrash(ctx context.Context, filter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	return historyRepository.repository.FindTrash(ctx, filter)
+}
+
+func (historyRepository *RedirectHistoryRepository) GetPurgeable(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.GetPurgeable(ctx, before, limit)
+}
+
+func (historyRepository *RedirectHistoryRepository) GetExpired(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError) {
+	return historyRepository.repository.GetExpired(ctx, before, limit)
+}
+
+func (historyRepository *RedirectHistoryRepository) IncrementClicks(ctx context.Context, id string) (int64, *exceptions.WrappedError) {
+	return historyRepository.repository.IncrementClicks(ctx, id)
+}
+
+func (historyRepository *RedirectHistoryRepository) Save(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	original := *redirect
+
+	return historyRepository.transaction(ctx, func(ctx context.Context) *exceptions.WrappedError {
+		*redirect = original
+
+		before, errw := historyRepository.previous(ctx, *redirect)
+		if errw != nil {
+			return errw
+		}
+
+		errw = historyRepository.repository.Save(ctx, redirect)
+		if errw != nil {
+			return errw
+		}
+
+		return historyRepository.record(ctx, saveAction(before), redirect.ID, before, redirect)
+	})
+}
+
+func (historyRepository *RedirectHistoryRepository) SaveMany(ctx context.Context, redirects []*entity.Redirect) *exceptions.WrappedError {
+	originals := make([]entity.Redirect, len(redirects))
+	for index, redirect := range redirects {
+		originals[index] = *redirect
+	}
+
+	return historyRepository.transaction(ctx, func(ctx context.Context) *exceptions.WrappedError {
+		befores := make([]*entity.Redirect, len(redirects))
+		for index, redirect := range redirects {
+			*redirect = originals[index]
+
+			before, errw := historyRepository.previous(ctx, *redirect)
+			if errw != nil {
+				return errw
+			}
+			befores[index] = before
+		}
+
+		errw := historyRepository.repository.SaveMany(ctx, redirects)
+		if errw != nil {
+			return errw
+		}
+
+		for index, redirect := range redirects {
+			errw = historyRepository.record(ctx, saveAction(befores[index]), redirect.ID, befores[index], redirect)
+			if errw != nil {
+				return errw
+			}
+		}
+		return nil
+	})
+}
+
+func (historyRepository *RedirectHistoryRepository) Remove(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError {
+	return historyRepository.transaction(ctx, func(ctx context.Context) *exceptions.WrappedError {
+		before, errw := historyRepository.previous(ctx, redirect)
+		if errw != nil {
+			return errw
+		}
+
+		errw = historyRepository.repository.Remove(ctx, redirect)
+		if errw != nil {
+			return errw
+		}
+
+		return historyRepository.record(ctx, actiontype.DELETE, redirect.ID, before, nil)
+	})
+}
+
+func (historyRepository *RedirectHistoryRepository) Restore(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	original := *redirect
+
+	return historyRepository.transaction(ctx, func(ctx context.Context) *exceptions.WrappedError {
+		*redirect = original
+
+		errw := historyRepository.repository.Restore(ctx, redirect)
+		if errw != nil {
+			return errw
+		}
+
+		return historyRepository.record(ctx, actiontype.RESTORE, redirect.ID, nil, redirect)
+	})
+}
+
+func (historyRepository *RedirectHistoryRepository) Purge(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError {
+	return historyRepository.transaction(ctx, func(ctx context.Context) *exceptions.WrappedError {
+		errw := historyRepository.repository.Purge(ctx, redirect)
+		if errw != nil {
+			return errw
+		}
+
+		return historyRepository.record(ctx, actiontype.PURGE, redirect.ID, &redirect, nil)
+	})
+}
+
+// transaction runs a write along with the insert of its revision in a single Mongo
+// transaction, so no change is applied without its revision. The write may run again
+// when the transaction is retried, so it must start over from the redirect it was
+// given. Deployments without transactions (a standalone server) run it once without
+// one, a failed revision then being reported although the change was applied.
+func (historyRepository *RedirectHistoryRepository) transaction(ctx context.Context, write func(ctx context.Context) *exceptions.WrappedError) *exceptions.WrappedError {
+	var errw *exceptions.WrappedError
+	err := inTransaction(ctx, utils.MongoDatabase.Client.Client(), func(ctx context.Context) error {
+		errw = write(ctx)
+		if errw != nil && errw.Error != nil {
+			return errw.Error
+		} else if errw != nil {
+			return errHistoryAborted
+		}
+		return nil
+	})
+
+	if errw != nil {
+		return errw
+	} else if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
+
+// previous returns the stored state of a redirect about to be written, or nil when it
+// is being created. A trashed redirect counts as stored, since saving over it (as a
+// rollback does) brings it back.
+func (historyRepository *RedirectHistoryRepository) previous(ctx context.Context, redirect entity.Redirect) (*entity.Redirect, *exceptions.WrappedError) {
+	if len(redirect.ID) == constants.ZERO {
+		return nil, nil
+	}
+
+	stored, errw := historyRepository.repository.Get(ctx, redirect.ID)
+	if errw != nil && errw.BaseError == exceptions.RecordNotFound {
+		stored, errw = historyRepository.repository.GetTrashed(ctx, redirect.ID)
+	}
+
+	if errw != nil && errw.BaseError == exceptions.RecordNotFound {
+		return nil, nil
+	} else if errw != nil {
+		return nil, errw
+	}
+
+	return &stored, nil
+}
+
+func saveAction(before *entity.Redirect) actiontype.Type {
+	if before == nil {
+		return actiontype.CREATE
+	}
+
+	return actiontype.UPDATE
+}
+
+// record inserts the revision of a write made in the same transaction, which a failure
+// aborts.
+func (historyRepository *RedirectHistoryRepository) record(ctx context.Context, action actiontype.Type, id string, before *entity.Redirect, after *entity.Redirect) *exceptions.WrappedError {
+	revision := &entity.Revision{
+		RedirectID: id,
+		Action:     action,
+		Before:     before,
+		After:      after,
+	}
+
+	revision.Principal, _ = ctx.Value(constants.PRINCIPAL).(string)
+	revision.IP, _ = ctx.Value(constants.CLIENT_IP).(string)
+	revision.RestoredRevision, _ = ctx.Value(constants.RESTORED_REVISION).(int64)
+
+	if errw := historyRepository.revisionRepository.Insert(ctx, revision); errw != nil {
+		log.Error(ctx).Msg("Error recording revision of redirect " + id + ": " + errw.GetMessage())
+		return errw
+	}
+
+	return nil
+}
@@ -2,38 +2,92 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fernandoglatz/url-management/internal/core/common/utils"
 	"fernandoglatz/url-management/internal/core/common/utils/constants"
 	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/common/utils/log"
 	"fernandoglatz/url-management/internal/core/entity"
+	"fernandoglatz/url-management/internal/core/model/request"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/metrics"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DEFAULT_SHORT_CODE_LENGTH    = 7
+	MONGO_ILLEGAL_OPERATION_CODE = 20
+	REDIRECT_COLLECTION          = "redirect"
+)
+
+var (
+	errVersionMismatch = errors.New("redirect version mismatch")
+	errShortCodeTaken  = errors.New("no free short code found")
+
+	// transactionsUnsupported is set once MongoDB refused a transaction
+	transactionsUnsupported atomic.Bool
 )
 
 type RedirectRepository struct {
-	collection *mongo.Collection
+	collection        *mongo.Collection
+	counterCollection *mongo.Collection
 }
 
 func NewRedirectRepository() *RedirectRepository {
 	return &RedirectRepository{
-		collection: utils.MongoDatabase.GetCollection("redirect"),
+		collection:        utils.MongoDatabase.GetCollection(REDIRECT_COLLECTION),
+		counterCollection: utils.MongoDatabase.GetCollection("redirect_counter"),
 	}
 }
 
 func (repository *RedirectRepository) Get(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
-	filter := bson.M{"id": id}
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "get", time.Now())
+
+	filter := liveFilter(bson.M{"id": id})
 	return repository.getByFilter(ctx, filter)
 }
 
-func (repository *RedirectRepository) GetByDNS(ctx context.Context, dns string) (entity.Redirect, *exceptions.WrappedError) {
-	filter := bson.M{"dns": dns}
+func (repository *RedirectRepository) GetAllByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "getAllByDNS", time.Now())
+
+	filter := liveFilter(bson.M{"dns": bson.M{"$in": dnsList}})
+	return repository.getAllByFilter(ctx, filter)
+}
+
+func (repository *RedirectRepository) GetTrashed(ctx context.Context, id string) (entity.Redirect, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "getTrashed", time.Now())
+
+	filter := trashFilter(bson.M{"id": id})
 	return repository.getByFilter(ctx, filter)
 }
 
+func (repository *RedirectRepository) GetTrashedByDNS(ctx context.Context, dnsList []string) ([]entity.Redirect, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "getTrashedByDNS", time.Now())
+
+	filter := trashFilter(bson.M{"dns": bson.M{"$in": dnsList}})
+	return repository.getAllByFilter(ctx, filter)
+}
+
+// liveFilter restricts a filter to redirects that are not in the trash.
+func liveFilter(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+// trashFilter restricts a filter to redirects that are in the trash.
+func trashFilter(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": true}
+	return filter
+}
+
 func (repository *RedirectRepository) getByFilter(ctx context.Context, filter interface{}) (entity.Redirect, *exceptions.WrappedError) {
 	var redirect entity.Redirect
 
@@ -53,9 +107,161 @@ func (repository *RedirectRepository) getByFilter(ctx context.Context, filter in
 }
 
 func (repository *RedirectRepository) GetAll(ctx context.Context) ([]entity.Redirect, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "getAll", time.Now())
+
+	return repository.getAllByFilter(ctx, liveFilter(bson.M{}))
+}
+
+func (repository *RedirectRepository) GetExpired(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "getExpired", time.Now())
+
+	filter := liveFilter(bson.M{"expiresAt": bson.M{"$lt": before}})
+	findOptions := options.Find().SetSort(bson.D{{Key: "expiresAt", Value: 1}}).SetLimit(int64(limit))
+	return repository.getAllByFilter(ctx, filter, findOptions)
+}
+
+// GetPurgeable returns the redirects moved to the trash before the given time, oldest first.
+func (repository *RedirectRepository) GetPurgeable(ctx context.Context, before time.Time, limit int) ([]entity.Redirect, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "getPurgeable", time.Now())
+
+	filter := bson.M{"deletedAt": bson.M{"$lt": before}}
+	findOptions := options.Find().SetSort(bson.D{{Key: "deletedAt", Value: 1}}).SetLimit(int64(limit))
+	return repository.getAllByFilter(ctx, filter, findOptions)
+}
+
+// IncrementClicks atomically counts a click of a redirect and returns the new total.
+// Counters live apart from the redirect so that saving a redirect never overwrites them.
+func (repository *RedirectRepository) IncrementClicks(ctx context.Context, id string) (int64, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "incrementClicks", time.Now())
+
+	filter := bson.M{"id": id}
+	update := bson.M{"$inc": bson.M{"clicks": 1}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Clicks int64 `bson:"clicks"`
+	}
+
+	err := repository.counterCollection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(&counter)
+	if err != nil {
+		return constants.ZERO, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return counter.Clicks, nil
+}
+
+// Find returns a page of the redirects matching the filter, along with the total
+// number of matches.
+func (repository *RedirectRepository) Find(ctx context.Context, redirectFilter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "find", time.Now())
+
+	filter := liveFilter(buildRedirectFilter(redirectFilter))
+	return repository.findPage(ctx, filter, redirectFilter)
+}
+
+// FindTrash returns a page of the trashed redirects matching the filter, along with
+// the total number of matches.
+func (repository *RedirectRepository) FindTrash(ctx context.Context, redirectFilter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "findTrash", time.Now())
+
+	filter := trashFilter(buildRedirectFilter(redirectFilter))
+	return repository.findPage(ctx, filter, redirectFilter)
+}
+
+func (repository *RedirectRepository) findPage(ctx context.Context, filter bson.M, redirectFilter request.RedirectFilter) ([]entity.Redirect, int64, *exceptions.WrappedError) {
+	total, err := repository.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return []entity.Redirect{}, constants.ZERO, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	skip := int64((redirectFilter.Page - constants.ONE) * redirectFilter.Size)
+	findOptions := options.Find().
+		SetSort(buildRedirectSort(redirectFilter.Sort)).
+		SetSkip(skip).
+		SetLimit(int64(redirectFilter.Size))
+
+	redirects, errw := repository.getAllByFilter(ctx, filter, findOptions)
+	return redirects, total, errw
+}
+
+func buildRedirectFilter(redirectFilter request.RedirectFilter) bson.M {
+	filter := bson.M{}
+
+	if utils.IsNotEmptyStr(redirectFilter.Type) {
+		filter["type"] = redirectFilter.Type
+	}
+
+	if utils.IsNotEmptyStr(redirectFilter.DNS) {
+		filter["dns"] = bson.M{"$regex": regexp.QuoteMeta(redirectFilter.DNS), "$options": "i"}
+	}
+
+	if utils.IsNotEmptyStr(redirectFilter.DestinationHost) {
+		pattern := `^[a-z][a-z0-9+.-]*://([^/?#@]*@)?[^/?#@]*` + regexp.QuoteMeta(redirectFilter.DestinationHost)
+		filter["destination"] = bson.M{"$regex": pattern, "$options": "i"}
+	}
+
+	if len(redirectFilter.Tags) > constants.ZERO {
+		filter["tags"] = bson.M{"$all": redirectFilter.Tags}
+	}
+
+	if dateFilter := buildDateFilter(redirectFilter.CreatedFrom, redirectFilter.CreatedTo); dateFilter != nil {
+		filter["createdAt"] = dateFilter
+	}
+
+	if dateFilter := buildDateFilter(redirectFilter.UpdatedFrom, redirectFilter.UpdatedTo); dateFilter != nil {
+		filter["updatedAt"] = dateFilter
+	}
+
+	return filter
+}
+
+func buildDateFilter(from time.Time, to time.Time) bson.M {
+	if from.IsZero() && to.IsZero() {
+		return nil
+	}
+
+	dateFilter := bson.M{}
+	if !from.IsZero() {
+		dateFilter["$gte"] = from
+	}
+	if !to.IsZero() {
+		dateFilter["$lte"] = to
+	}
+
+	return dateFilter
+}
+
+// buildRedirectSort converts "-createdAt,dns" into a Mongo sort, always ending with
+// the id so that pages are stable.
+func buildRedirectSort(sort string) bson.D {
+	sortDocument := bson.D{}
+
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if utils.IsEmptyStr(field) {
+			continue
+		}
+
+		direction := constants.ONE
+		if strings.HasPrefix(field, "-") {
+			direction = -constants.ONE
+			field = field[constants.ONE:]
+		}
+
+		sortDocument = append(sortDocument, bson.E{Key: field, Value: direction})
+	}
+
+	return append(sortDocument, bson.E{Key: "id", Value: constants.ONE})
+}
+
+func (repository *RedirectRepository) getAllByFilter(ctx context.Context, filter interface{}, findOptions ...*options.FindOptions) ([]entity.Redirect, *exceptions.WrappedError) {
 	var redirects []entity.Redirect = []entity.Redirect{}
 
-	cursor, err := repository.collection.Find(ctx, bson.D{})
+	cursor, err := repository.collection.Find(ctx, filter, findOptions...)
 	if err != nil {
 		return redirects, &exceptions.WrappedError{
 			Error: err,
@@ -81,39 +287,306 @@ func (repository *RedirectRepository) GetAll(ctx context.Context) ([]entity.Redi
 }
 
 func (repository *RedirectRepository) Save(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "save", time.Now())
+
 	now := time.Now()
 	redirect.UpdatedAt = now
+	redirect.DeletedAt = nil
 
 	if len(redirect.ID) == constants.ZERO {
-		uuidObj, _ := uuid.NewRandom()
-		uuidStr := uuidObj.String()
-		redirect.ID = strings.Replace(uuidStr, "-", "", -1)
+		redirect.CreatedAt = now
+		redirect.Version = constants.ONE
+		return repository.insertWithShortCode(ctx, redirect)
 	}
 
 	if redirect.CreatedAt.IsZero() {
 		redirect.CreatedAt = now
+		redirect.Version = constants.ONE
 
 		_, err := repository.collection.InsertOne(ctx, redirect)
-		if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return &exceptions.WrappedError{
+				BaseError: exceptions.RecordAlreadyExists,
+				Message:   "Redirect [" + redirect.ID + "] already exists",
+			}
+		} else if err != nil {
 			return &exceptions.WrappedError{
 				Error: err,
 			}
 		}
 
 	} else {
-		filter := bson.M{"id": redirect.ID}
-		_, err := repository.collection.ReplaceOne(ctx, filter, redirect)
+		// the replace only applies to the version that was read, so concurrent writes
+		// can't overwrite each other
+		version := redirect.Version
+		redirect.Version++
+
+		filter := bson.M{"id": redirect.ID, "version": version}
+		result, err := repository.collection.ReplaceOne(ctx, filter, redirect)
 		if err != nil {
+			redirect.Version = version
 			return &exceptions.WrappedError{
 				Error: err,
 			}
+		} else if result.MatchedCount == constants.ZERO {
+			redirect.Version = version
+			return versionMismatch(redirect.ID, version)
+		}
+	}
+
+	return nil
+}
+
+func versionMismatch(id string, version int64) *exceptions.WrappedError {
+	return &exceptions.WrappedError{
+		BaseError: exceptions.PreconditionFailed,
+		Message:   "Redirect [" + id + "] is no longer at version " + strconv.FormatInt(version, 10),
+	}
+}
+
+// SaveMany writes a batch of redirects in a single transaction, so an import is applied
+// entirely or not at all, joining the one of the context if any. Deployments without
+// transactions (a standalone server) fall back to an ordered bulk write, which stops
+// at the first failure.
+func (repository *RedirectRepository) SaveMany(ctx context.Context, redirects []*entity.Redirect) *exceptions.WrappedError {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "saveMany", time.Now())
+
+	if len(redirects) == constants.ZERO {
+		return nil
+	}
+
+	bulkOptions := options.BulkWrite().SetOrdered(true)
+	now := time.Now()
+	models := make([]mongo.WriteModel, constants.ZERO, len(redirects))
+	replacements := constants.ZERO
+
+	reserved := make(map[string]bool)
+	for _, redirect := range redirects {
+		if len(redirect.ID) > constants.ZERO {
+			reserved[redirect.ID] = true
+		}
+	}
+
+	for _, redirect := range redirects {
+		redirect.UpdatedAt = now
+		redirect.DeletedAt = nil
+
+		if len(redirect.ID) == constants.ZERO {
+			id, err := repository.newShortCode(ctx, reserved)
+			if err != nil {
+				return &exceptions.WrappedError{
+					Error: err,
+				}
+			}
+			redirect.ID = id
+			reserved[id] = true
+		}
+
+		if redirect.CreatedAt.IsZero() {
+			redirect.CreatedAt = now
+			redirect.Version = constants.ONE
+			models = append(models, mongo.NewInsertOneModel().SetDocument(redirect))
+		} else {
+			filter := bson.M{"id": redirect.ID, "version": redirect.Version}
+			redirect.Version++
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(redirect))
+			replacements++
+		}
+	}
+
+	// a replace matching nothing isn't an error for Mongo, so a changed version is
+	// detected from the counts and aborts the transaction
+	err := inTransaction(ctx, repository.collection.Database().Client(), func(ctx context.Context) error {
+		result, err := repository.collection.BulkWrite(ctx, models, bulkOptions)
+		if err == nil && result.MatchedCount < int64(replacements) {
+			err = errVersionMismatch
+		}
+		return err
+	})
+
+	if err == errVersionMismatch {
+		return &exceptions.WrappedError{
+			BaseError: exceptions.PreconditionFailed,
+			Message:   "Redirects of the batch were modified by another request",
+		}
+	} else if mongo.IsDuplicateKeyError(err) {
+		return &exceptions.WrappedError{
+			BaseError: exceptions.RecordAlreadyExists,
+			Error:     err,
+		}
+	} else if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
 		}
 	}
 
 	return nil
 }
 
+// inTransaction runs a write in a transaction, joining the one of the context if any.
+// Deployments without transactions (a standalone server) run it without one: the
+// first failed attempt is remembered, so the following writes don't try again.
+func inTransaction(ctx context.Context, client *mongo.Client, write func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil || transactionsUnsupported.Load() {
+		return write(ctx)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, write(sessionCtx)
+	})
+
+	if isTransactionUnsupported(err) {
+		if !transactionsUnsupported.Swap(true) {
+			log.Warn(ctx).Msg("MongoDB doesn't support transactions, writing without them from now on")
+		}
+		return write(ctx)
+	}
+
+	return err
+}
+
+// isTransactionUnsupported reports whether an error comes from starting a transaction
+// on a standalone server, which only replica sets and sharded clusters allow.
+func isTransactionUnsupported(err error) bool {
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) {
+		return commandError.Code == MONGO_ILLEGAL_OPERATION_CODE && strings.Contains(commandError.Message, "Transaction numbers")
+	}
+
+	return false
+}
+
+func (repository *RedirectRepository) shortCodeLength() int {
+	length := config.ApplicationConfig.Redirect.ShortCode.Length
+	if length <= constants.ZERO {
+		length = DEFAULT_SHORT_CODE_LENGTH
+	}
+
+	return length
+}
+
+// insertWithShortCode generates a random short code as the ID, skipping the codes
+// already used. The unique id index still catches a code taken concurrently, retrying
+// with a new one, except in a transaction which the collision aborts.
+func (repository *RedirectRepository) insertWithShortCode(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	var err error
+	for attempt := constants.ZERO; attempt < repository.shortCodeAttempts(); attempt++ {
+		redirect.ID, err = repository.newShortCode(ctx, nil)
+		if err != nil {
+			break
+		}
+
+		_, err = repository.collection.InsertOne(ctx, redirect)
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		redirect.ID = ""
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
+
+// newShortCode draws random short codes until one is used neither by a redirect,
+// trashed or not, nor among the reserved ones of the batch being written.
+func (repository *RedirectRepository) newShortCode(ctx context.Context, reserved map[string]bool) (string, error) {
+	length := repository.shortCodeLength()
+
+	for attempt := constants.ZERO; attempt < repository.shortCodeAttempts(); attempt++ {
+		id, err := utils.GenerateShortCode(length)
+		if err != nil {
+			return "", err
+		}
+		if reserved[id] {
+			continue
+		}
+
+		count, err := repository.collection.CountDocuments(ctx, bson.M{"id": id}, options.Count().SetLimit(constants.ONE))
+		if err != nil {
+			return "", err
+		} else if count == constants.ZERO {
+			return id, nil
+		}
+	}
+
+	return "", errShortCodeTaken
+}
+
+func (repository *RedirectRepository) shortCodeAttempts() int {
+	maxAttempts := config.ApplicationConfig.Redirect.ShortCode.MaxAttempts
+	if maxAttempts <= constants.ZERO {
+		maxAttempts = constants.ONE
+	}
+
+	return maxAttempts
+}
+
+// Remove moves a redirect to the trash, keeping it until it's restored or purged.
 func (repository *RedirectRepository) Remove(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "remove", time.Now())
+
+	filter := liveFilter(bson.M{"id": redirect.ID, "version": redirect.Version})
+	update := bson.M{
+		"$set": bson.M{"deletedAt": time.Now()},
+		"$inc": bson.M{"version": constants.ONE},
+	}
+
+	result, err := repository.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	} else if result.MatchedCount == constants.ZERO {
+		return versionMismatch(redirect.ID, redirect.Version)
+	}
+
+	return nil
+}
+
+// Restore takes a redirect out of the trash.
+func (repository *RedirectRepository) Restore(ctx context.Context, redirect *entity.Redirect) *exceptions.WrappedError {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "restore", time.Now())
+
+	now := time.Now()
+	filter := trashFilter(bson.M{"id": redirect.ID})
+	update := bson.M{
+		"$unset": bson.M{"deletedAt": ""},
+		"$set":   bson.M{"updatedAt": now},
+		"$inc":   bson.M{"version": constants.ONE},
+	}
+
+	result, err := repository.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	} else if result.MatchedCount == constants.ZERO {
+		return &exceptions.WrappedError{
+			BaseError: exceptions.RecordNotFound,
+		}
+	}
+
+	redirect.DeletedAt = nil
+	redirect.UpdatedAt = now
+	redirect.Version++
+	return nil
+}
+
+// Purge deletes a redirect for good, along with its click counter.
+func (repository *RedirectRepository) Purge(ctx context.Context, redirect entity.Redirect) *exceptions.WrappedError {
+	defer metrics.ObserveMongo(REDIRECT_COLLECTION, "purge", time.Now())
+
 	filter := bson.M{"id": redirect.ID}
 	_, err := repository.collection.DeleteOne(ctx, filter)
 	if err != nil {
@@ -122,6 +595,13 @@ func (repository *RedirectRepository) Remove(ctx context.Context, redirect entit
 		}
 	}
 
+	_, err = repository.counterCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
 	return nil
 }
 
@@ -129,4 +609,9 @@ func (repository *RedirectRepository) correctTimezone(redirect *entity.Redirect)
 	location, _ := time.LoadLocation(utils.GetTimezone())
 	redirect.CreatedAt = redirect.CreatedAt.In(location)
 	redirect.UpdatedAt = redirect.UpdatedAt.In(location)
+
+	if redirect.DeletedAt != nil {
+		deletedAt := redirect.DeletedAt.In(location)
+		redirect.DeletedAt = &deletedAt
+	}
 }
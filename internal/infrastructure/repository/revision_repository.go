@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const MAXIMUM_REVISION_ATTEMPTS = 5
+
+// RevisionRepository stores the history of redirects. Revisions are only ever
+// inserted: there is no way to change or remove one.
+type RevisionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRevisionRepository() *RevisionRepository {
+	return &RevisionRepository{
+		collection: utils.MongoDatabase.GetCollection("redirect_history"),
+	}
+}
+
+func (repository *RevisionRepository) Get(ctx context.Context, redirectID string, revision int64) (entity.Revision, *exceptions.WrappedError) {
+	var found entity.Revision
+
+	filter := bson.M{"redirectId": redirectID, "revision": revision}
+	err := repository.collection.FindOne(ctx, filter).Decode(&found)
+	if err == mongo.ErrNoDocuments {
+		return found, &exceptions.WrappedError{
+			BaseError: exceptions.RecordNotFound,
+		}
+	} else if err != nil {
+		return found, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	repository.correctTimezone(&found)
+	return found, nil
+}
+
+// Find returns a page of the revisions of a redirect, newest first, along with their
+// total number.
+func (repository *RevisionRepository) Find(ctx context.Context, redirectID string, page int, size int) ([]entity.Revision, int64, *exceptions.WrappedError) {
+	var revisions []entity.Revision = []entity.Revision{}
+	filter := bson.M{"redirectId": redirectID}
+
+	total, err := repository.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return revisions, constants.ZERO, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: -constants.ONE}}).
+		SetSkip(int64((page - constants.ONE) * size)).
+		SetLimit(int64(size))
+
+	cursor, err := repository.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return revisions, constants.ZERO, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var revision entity.Revision
+		err = cursor.Decode(&revision)
+		if err != nil {
+			return revisions, constants.ZERO, &exceptions.WrappedError{
+				Error: err,
+			}
+		}
+
+		repository.correctTimezone(&revision)
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, total, nil
+}
+
+// Insert numbers the revision after the latest one of its redirect. The unique index
+// on the number settles concurrent changes, the loser retrying with the next number.
+func (repository *RevisionRepository) Insert(ctx context.Context, revision *entity.Revision) *exceptions.WrappedError {
+	revision.CreatedAt = time.Now()
+
+	var err error
+	for attempt := constants.ZERO; attempt < MAXIMUM_REVISION_ATTEMPTS; attempt++ {
+		var latest entity.Revision
+		findOptions := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -constants.ONE}}).SetProjection(bson.M{"revision": constants.ONE})
+
+		err = repository.collection.FindOne(ctx, bson.M{"redirectId": revision.RedirectID}, findOptions).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			break
+		}
+
+		revision.Revision = latest.Revision + constants.ONE
+		_, err = repository.collection.InsertOne(ctx, revision)
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
+
+func (repository *RevisionRepository) correctTimezone(revision *entity.Revision) {
+	location, _ := time.LoadLocation(utils.GetTimezone())
+	revision.CreatedAt = revision.CreatedAt.In(location)
+
+	for _, redirect := range []*entity.Redirect{revision.Before, revision.After} {
+		if redirect != nil {
+			redirect.CreatedAt = redirect.CreatedAt.In(location)
+			redirect.UpdatedAt = redirect.UpdatedAt.In(location)
+		}
+	}
+}
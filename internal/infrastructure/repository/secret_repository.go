@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SecretRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSecretRepository() *SecretRepository {
+	return &SecretRepository{
+		collection: utils.MongoDatabase.GetCollection("secret"),
+	}
+}
+
+// GetOrCreate returns the stored value of a secret, storing the given one when there is
+// none yet. Replicas starting together all get the value of the first to store it.
+func (repository *SecretRepository) GetOrCreate(ctx context.Context, name string, value []byte) ([]byte, *exceptions.WrappedError) {
+	filter := bson.M{"name": name}
+	update := bson.M{"$setOnInsert": entity.Secret{
+		Name:      name,
+		Value:     value,
+		CreatedAt: time.Now(),
+	}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var secret entity.Secret
+	err := repository.collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(&secret)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent upsert inserted it first
+		err = repository.collection.FindOne(ctx, filter).Decode(&secret)
+	}
+
+	if err != nil {
+		return nil, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return secret.Value, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils"
+	"fernandoglatz/url-management/internal/core/common/utils/exceptions"
+	"fernandoglatz/url-management/internal/core/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type VariantRepository struct {
+	collection *mongo.Collection
+}
+
+func NewVariantRepository() *VariantRepository {
+	return &VariantRepository{
+		collection: utils.MongoDatabase.GetCollection("variant_counter"),
+	}
+}
+
+func (repository *VariantRepository) IncrementHits(ctx context.Context, redirectID string, variant string) *exceptions.WrappedError {
+	return repository.increment(ctx, redirectID, variant, "hits")
+}
+
+func (repository *VariantRepository) IncrementConversions(ctx context.Context, redirectID string, variant string) *exceptions.WrappedError {
+	return repository.increment(ctx, redirectID, variant, "conversions")
+}
+
+func (repository *VariantRepository) increment(ctx context.Context, redirectID string, variant string, field string) *exceptions.WrappedError {
+	filter := bson.M{"redirectId": redirectID, "variant": variant}
+	update := bson.M{"$inc": bson.M{field: 1}}
+
+	_, err := repository.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	return nil
+}
+
+func (repository *VariantRepository) GetAll(ctx context.Context, redirectID string) ([]entity.VariantCounter, *exceptions.WrappedError) {
+	var counters []entity.VariantCounter = []entity.VariantCounter{}
+
+	cursor, err := repository.collection.Find(ctx, bson.M{"redirectId": redirectID})
+	if err != nil {
+		return counters, &exceptions.WrappedError{
+			Error: err,
+		}
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var counter entity.VariantCounter
+		err = cursor.Decode(&counter)
+		if err != nil {
+			return counters, &exceptions.WrappedError{
+				Error: err,
+			}
+		}
+
+		counters = append(counters, counter)
+	}
+
+	return counters, nil
+}
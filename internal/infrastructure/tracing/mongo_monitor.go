@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mongoMonitor opens a client span for every command the Mongo driver sends, ending it
+// when the reply or the failure arrives. Commands issued outside a trace, such as the
+// migrations, the sweepers or the connection handshakes, aren't traced.
+type mongoMonitor struct {
+	spans sync.Map
+}
+
+func NewMongoMonitor() *event.CommandMonitor {
+	monitor := &mongoMonitor{}
+
+	return &event.CommandMonitor{
+		Started:   monitor.started,
+		Succeeded: monitor.succeeded,
+		Failed:    monitor.failed,
+	}
+}
+
+func (monitor *mongoMonitor) started(ctx context.Context, startedEvent *event.CommandStartedEvent) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	name := startedEvent.CommandName
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system.name", "mongodb"),
+		attribute.String("db.namespace", startedEvent.DatabaseName),
+		attribute.String("db.operation.name", startedEvent.CommandName),
+	}
+
+	if collection, ok := startedEvent.Command.Lookup(startedEvent.CommandName).StringValueOK(); ok {
+		name += " " + collection
+		attributes = append(attributes, attribute.String("db.collection.name", collection))
+	}
+
+	_, span := Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	monitor.spans.Store(startedEvent.RequestID, span)
+}
+
+func (monitor *mongoMonitor) succeeded(ctx context.Context, succeededEvent *event.CommandSucceededEvent) {
+	if span, ok := monitor.spans.LoadAndDelete(succeededEvent.RequestID); ok {
+		span.(trace.Span).End()
+	}
+}
+
+func (monitor *mongoMonitor) failed(ctx context.Context, failedEvent *event.CommandFailedEvent) {
+	if span, ok := monitor.spans.LoadAndDelete(failedEvent.RequestID); ok {
+		span.(trace.Span).SetStatus(codes.Error, failedEvent.Failure)
+		span.(trace.Span).End()
+	}
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"fernandoglatz/url-management/internal/core/common/utils/constants"
+	"fernandoglatz/url-management/internal/infrastructure/config"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TRACER_NAME          = "fernandoglatz/url-management"
+	DEFAULT_SERVICE_NAME = "url-management"
+	DEFAULT_SAMPLE_RATIO = 1.0
+)
+
+// Setup installs the W3C trace context propagator and, when tracing is enabled, a
+// tracer provider exporting through OTLP over HTTP. The propagator is installed even
+// when tracing is disabled, so an incoming traceparent still reaches the upstreams.
+// It returns the function flushing the spans still buffered, to call on shutdown.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	tracingConfig := config.ApplicationConfig.Tracing
+	if !tracingConfig.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporterOptions []otlptracehttp.Option
+	if tracingConfig.OTLP.Endpoint != "" {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithEndpoint(tracingConfig.OTLP.Endpoint))
+	}
+	if tracingConfig.OTLP.Insecure {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+	}
+	if len(tracingConfig.OTLP.Headers) > constants.ZERO {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithHeaders(tracingConfig.OTLP.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := Install(ctx, sdktrace.WithBatcher(exporter))
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Shutdown, nil
+}
+
+// Install makes a tracer provider exporting through the given span processor the
+// global one, e.g. sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) to inspect the
+// spans in tests.
+func Install(ctx context.Context, processor sdktrace.TracerProviderOption) (*sdktrace.TracerProvider, error) {
+	tracingConfig := config.ApplicationConfig.Tracing
+
+	serviceName := tracingConfig.ServiceName
+	if serviceName == "" {
+		serviceName = DEFAULT_SERVICE_NAME
+	}
+
+	sampleRatio := tracingConfig.SampleRatio
+	if sampleRatio <= constants.ZERO {
+		sampleRatio = DEFAULT_SAMPLE_RATIO
+	}
+
+	serviceResource, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider, nil
+}
+
+// Start starts a span with the tracer of the current global provider, which a
+// package-level tracer wouldn't follow past the first Install.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TRACER_NAME).Start(ctx, name, options...)
+}
+
+// Extract returns the context carrying the remote span found in the headers, if any.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject writes the traceparent of the span in the context to the headers.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
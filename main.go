@@ -6,6 +6,10 @@ import (
 	"fernandoglatz/url-management/internal/core/common/utils/log"
 	"fernandoglatz/url-management/internal/core/server"
 	"fernandoglatz/url-management/internal/infrastructure/config"
+	"fernandoglatz/url-management/internal/infrastructure/tracing"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 )
@@ -25,7 +29,8 @@ import (
 // @description     Generated by /authentication
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	godotenv.Load()
 
 	err := config.LoadConfig(ctx)
@@ -33,6 +38,11 @@ func main() {
 		log.Fatal(ctx).Msg(err.Error())
 	}
 
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Fatal(ctx).Msg(err.Error())
+	}
+
 	err = utils.ConnectToMongoDB(ctx)
 	if err != nil {
 		log.Fatal(ctx).Msg(err.Error())
@@ -43,8 +53,26 @@ func main() {
 		log.Fatal(ctx).Msg(err.Error())
 	}
 
+	err = utils.ConnectToGeoIP(ctx)
+	if err != nil {
+		log.Fatal(ctx).Msg(err.Error())
+	}
+
 	err = server.Setup(ctx)
+
+	// the clients are closed once the server is drained, so without the signal
+	ctx = context.WithoutCancel(ctx)
+	disconnect(ctx, utils.DisconnectFromMongoDB)
+	disconnect(ctx, utils.DisconnectFromRedis)
+	disconnect(ctx, shutdownTracing)
+
 	if err != nil {
 		log.Fatal(ctx).Msg(err.Error())
 	}
 }
+
+func disconnect(ctx context.Context, disconnectFunc func(context.Context) error) {
+	if err := disconnectFunc(ctx); err != nil {
+		log.Error(ctx).Msg(err.Error())
+	}
+}